@@ -58,6 +58,38 @@ timeout_idle="1m"
 [log]
 verbose=0
 pretty=0
+[runner]
+executor="docker"
+pull_policy="if_not_present"
+instance=""
+[runner.local]
+command=[]
+prlimit="prlimit"
+[runner.sandbox]
+network_mode="none"
+user="1000:1000"
+memory_mb=512
+cpus=1.0
+pids_limit=256
+tmpfs_mb=128
+read_only_rootfs=1
+timeout=300
+[runner.images]
+allowed=["docker.io/yarcode"]
+[tasks]
+retention="24h"
+dir="var/tasks"
+[workers]
+count=0
+queue_size=100
+retry_after="30s"
+[postback]
+timeout="10s"
+initial_interval="1s"
+max_interval="5m"
+max_period="6h"
+[auth]
+token=""
 `)
 	logger.CheckErr(viper.ReadConfig(bytes.NewBuffer(defaultConfig)))
 
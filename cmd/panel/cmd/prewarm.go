@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"github.com/spf13/cobra"
+	"grader/internal/app/panel/pkg/grader"
+	"grader/internal/app/panel/storage/postgres"
+	"grader/pkg/logger"
+)
+
+// prewarmCmd represents the prewarm command
+var prewarmCmd = &cobra.Command{
+	Use:   "prewarm",
+	Short: "Pull the assessment images on the grader host",
+	Long:  `Asks the grader to pull the pinned images of all the assessments, so submissions do not wait for them`,
+	Run: func(cmd *cobra.Command, args []string) {
+		prewarm()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(prewarmCmd)
+}
+
+func prewarm() {
+	l := logger.Global()
+	ctx := context.Background()
+
+	db, err := getDb()
+	logger.CheckErr(err)
+
+	assessments, err := postgres.NewAssessmentRepository(db)
+	logger.CheckErr(err)
+
+	models, err := assessments.All(ctx)
+	logger.CheckErr(err)
+
+	graders, err := postgres.NewGraderRepository(db)
+	logger.CheckErr(err)
+
+	registry := grader.NewRegistry(grader.NewClient(cfg.Grader.URL, cfg.Grader.Secret), graders)
+
+	results := registry.Prewarm(ctx, models)
+	if len(results) == 0 {
+		l.Info().Msg("No pinned images to pre-warm")
+		return
+	}
+
+	for _, r := range results {
+		if r.Error != "" {
+			l.Error().Str("grader", r.Grader).Str("container_image", r.Image).Str("error", r.Error).Msg("Image is not ready")
+			continue
+		}
+		l.Info().Str("grader", r.Grader).Str("container_image", r.Image).Msg("Image is ready")
+	}
+}
@@ -58,6 +58,8 @@ listen="localhost:8080"
 timeout_read="5s"
 timeout_write="5s"
 timeout_idle="1m"
+[http]
+public_url="http://localhost:8080"
 [log]
 verbose=0
 pretty=0
@@ -76,6 +78,12 @@ disable_ssl=0
 host=""
 password=""
 db=0
+[security]
+secret_key=""
+callback_token_lifetime="12h"
+[grader]
+url="http://localhost:8090"
+secret=""
 `)
 	logger.CheckErr(viper.ReadConfig(bytes.NewBuffer(defaultConfig)))
 
@@ -54,6 +54,14 @@ func initConfig() {
 topic_name="grader-submissions"
 [amqp]
 dsn=""
+[db]
+dsn=""
+[grader]
+url="http://localhost:8090"
+secret=""
+timeout="10s"
+[panel]
+url="http://localhost:8080"
 [log]
 verbose=0
 pretty=0
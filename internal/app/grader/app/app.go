@@ -1,58 +1,169 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
 	"grader/internal/app/grader/config"
 	"grader/internal/app/grader/handler"
+	"grader/internal/app/grader/postback"
+	"grader/internal/app/grader/runner"
+	"grader/internal/app/grader/task"
 	"grader/pkg/httpserver"
 	"grader/pkg/logger"
 	mw "grader/pkg/middleware"
 	"grader/pkg/workerpool"
+	"io"
 	"runtime"
 )
 
 type App struct {
-	config  config.Config
-	logger  logger.Logger
-	stop    chan struct{}
-	server  *httpserver.Server
-	workers *workerpool.Pool
+	config   config.Config
+	logger   logger.Logger
+	stop     chan struct{}
+	server   *httpserver.Server
+	workers  *workerpool.Pool
+	outbox   *postback.Outbox
+	executor runner.Executor
+	cancel   context.CancelFunc
 }
 
 func New(cfg config.Config) (*App, error) {
 	l := *logger.Global()
 
-	wp := workerpool.New()
+	exec, err := runner.NewExecutor(cfg.Runner)
+	if err != nil {
+		return nil, fmt.Errorf("executor: %w", err)
+	}
+
+	// running jobs are cancelled on stop so their containers get removed
+	ctx, cancel := context.WithCancel(context.Background())
+
+	wp := workerpool.New(workerpool.WithQueueSize(cfg.Workers.QueueSize))
+	wp.DefaultContext = func() context.Context {
+		return ctx
+	}
+
+	var opts []task.Option
+	if cfg.Tasks.Dir != "" {
+		store, err := task.NewFileStore(cfg.Tasks.Dir)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("task store: %w", err)
+		}
+		opts = append(opts, task.WithStore(store))
+	}
+	tasks := task.NewRegistry(cfg.Tasks.Retention, opts...)
+
+	queued, undelivered, err := tasks.Restore()
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("restore tasks: %w", err)
+	}
+	l.Info().Int("queued", len(queued)).Int("undelivered", len(undelivered)).Msg("Tasks restored")
+
+	// containers of this instance are of no use unless their tasks are still running,
+	// the restored tasks never are, so the interrupted ones lose their containers
+	n, err := runner.RemoveOrphans(context.Background(), exec, func(id uuid.UUID) bool {
+		t, err := tasks.Get(id)
+		return err == nil && t.Status == task.StatusRunning
+	})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("remove orphans: %w", err)
+	}
+	l.Info().Int("count", n).Msg("Orphaned containers removed")
+
+	outbox := postback.NewOutbox(cfg.Postback, tasks)
 
 	r := chi.NewRouter()
 	r.Use(middleware.Recoverer)
 	r.Use(mw.Log(l))
+	r.Use(mw.BearerToken(cfg.Auth.Token))
 
-	ah := handler.NewSubmissionHandler(wp)
+	ah := handler.NewSubmissionHandler(
+		wp,
+		cfg.Runner,
+		exec,
+		tasks,
+		outbox,
+		handler.WithRetryAfter(cfg.Workers.RetryAfter),
+	)
 	r.Post("/submissions", ah.Check)
+	r.Get("/submissions", ah.List)
+	r.Get("/submissions/{task_id}", ah.Read)
+	r.Get("/submissions/{task_id}/logs", ah.Logs)
+	r.Delete("/submissions/{task_id}", ah.Cancel)
+
+	dh := handler.NewDeliveryHandler(tasks, outbox)
+	r.Get("/deliveries", dh.List)
+	r.Post("/deliveries/replay", dh.ReplayAll)
+	r.Post("/deliveries/{task_id}/replay", dh.Replay)
+
+	ih := handler.NewImageHandler(cfg.Runner.Images, exec)
+	r.Post("/images/resolve", ih.Resolve)
+	r.Post("/images/prewarm", ih.Prewarm)
 
 	hs, err := httpserver.New(cfg.Server, r, httpserver.WithLogger(l.Logger))
 	if err != nil {
+		cancel()
 		return nil, fmt.Errorf("http server: %w", err)
 	}
 
 	a := &App{
-		config:  cfg,
-		logger:  l,
-		stop:    make(chan struct{}),
-		server:  hs,
-		workers: wp,
+		config:   cfg,
+		logger:   l,
+		stop:     make(chan struct{}),
+		server:   hs,
+		workers:  wp,
+		outbox:   outbox,
+		executor: exec,
+		cancel:   cancel,
 	}
 
-	wp.Start(runtime.GOMAXPROCS(0) * 2)
+	workers := cfg.Workers.Count
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0) * 2
+	}
+	wp.Start(workers)
+
+	go resume(ctx, l, wp, exec, cfg.Runner, tasks, outbox, queued)
+	for _, t := range undelivered {
+		outbox.Report(t.Submission, *t.Result)
+	}
 
 	return a, nil
 }
 
+// resume tasks accepted by the previous run, they may not fit the queue at once
+func resume(
+	ctx context.Context,
+	l logger.Logger,
+	wp *workerpool.Pool,
+	exec runner.Executor,
+	cfg runner.Config,
+	tasks *task.Registry,
+	reporter runner.Reporter,
+	queued []task.Task,
+) {
+	for _, t := range queued {
+		if err := wp.Submit(ctx, runner.CheckSubmissionJob(exec, cfg, t.Submission, tasks, reporter)); err != nil {
+			// left queued to be resumed by the next run
+			l.Warn().Err(err).Str("task_id", t.ID.String()).Msg("Unable to resume task")
+			return
+		}
+	}
+}
+
 func (a *App) Stop() {
 	close(a.stop)
 	a.server.Stop()
+	a.cancel()
 	a.workers.Stop()
+	a.outbox.Stop()
+	if c, ok := a.executor.(io.Closer); ok {
+		_ = c.Close()
+	}
 }
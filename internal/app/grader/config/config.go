@@ -1,11 +1,40 @@
 package config
 
 import (
+	"grader/internal/app/grader/postback"
+	"grader/internal/app/grader/runner"
 	"grader/pkg/httpserver"
 	"grader/pkg/logger"
+	"time"
 )
 
 type Config struct {
-	Server httpserver.Config `mapstructure:"server"`
-	Logger logger.Config     `mapstructure:"log"`
+	Server   httpserver.Config `mapstructure:"server"`
+	Logger   logger.Config     `mapstructure:"log"`
+	Runner   runner.Config     `mapstructure:"runner"`
+	Tasks    TasksConfig       `mapstructure:"tasks"`
+	Workers  WorkersConfig     `mapstructure:"workers"`
+	Postback postback.Config   `mapstructure:"postback"`
+	Auth     AuthConfig        `mapstructure:"auth"`
+}
+
+type AuthConfig struct {
+	// Token the clients present as the bearer token, the API is open if empty
+	Token string `mapstructure:"token"`
+}
+
+type WorkersConfig struct {
+	// Count of the workers, twice the number of CPUs if zero
+	Count int `mapstructure:"count"`
+	// QueueSize of the submissions waiting for a free worker
+	QueueSize int `mapstructure:"queue_size"`
+	// RetryAfter suggested to the clients when the queue is full
+	RetryAfter time.Duration `mapstructure:"retry_after"`
+}
+
+type TasksConfig struct {
+	// Retention of the finished tasks in the registry
+	Retention time.Duration `mapstructure:"retention"`
+	// Dir where the tasks are kept across restarts, in memory only if empty
+	Dir string `mapstructure:"dir"`
 }
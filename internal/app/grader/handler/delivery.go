@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"grader/internal/app/grader/postback"
+	"grader/internal/app/grader/runner"
+	"grader/internal/app/grader/task"
+	"grader/internal/pkg/graderapi"
+	"grader/pkg/apperr"
+	"grader/pkg/httputil"
+	"net/http"
+)
+
+type DeliveryHandler struct {
+	tasks    *task.Registry
+	reporter runner.Reporter
+}
+
+func NewDeliveryHandler(tasks *task.Registry, reporter runner.Reporter) *DeliveryHandler {
+	return &DeliveryHandler{
+		tasks:    tasks,
+		reporter: reporter,
+	}
+}
+
+type ListDeliveriesResponse struct {
+	Tasks []task.Task `json:"tasks"`
+}
+
+// List tasks by the delivery status of their results, dead ones by default
+func (h *DeliveryHandler) List(w http.ResponseWriter, r *http.Request) {
+	status := postback.DeliveryStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "":
+		status = postback.DeliveryDead
+	case postback.DeliveryPending, postback.DeliveryDelivered, postback.DeliveryDead:
+	default:
+		httputil.WriteError(w, fmt.Errorf("status: %w", apperr.ErrInvalidInput), http.StatusBadRequest)
+		return
+	}
+
+	out := &ListDeliveriesResponse{
+		Tasks: h.tasks.ListDeliveries(status),
+	}
+
+	httputil.WriteResponse(w, out, http.StatusOK)
+}
+
+// Replay the dead result of the task
+func (h *DeliveryHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "task_id"))
+	if err != nil {
+		httputil.WriteError(w, fmt.Errorf("task id: %w", apperr.ErrInvalidInput), http.StatusBadRequest)
+		return
+	}
+
+	t, err := h.tasks.Get(id)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			httputil.WriteError(w, err, http.StatusNotFound)
+			return
+		}
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	if t.Result == nil || t.Delivery == nil || t.Delivery.Status != postback.DeliveryDead {
+		httputil.WriteError(w, fmt.Errorf("result is not dead: %w", apperr.ErrConflict), http.StatusConflict)
+		return
+	}
+	// replay resends the same token, the panel would refuse it again
+	if t.Delivery.TokenRejected() {
+		httputil.WriteError(w, fmt.Errorf("postback token is rejected, the submission has to be checked again: %w", apperr.ErrConflict), http.StatusConflict)
+		return
+	}
+
+	h.reporter.Report(t.Submission, *t.Result)
+
+	httputil.WriteResponse(w, &graderapi.CheckSubmissionResponse{TaskID: t.ID}, http.StatusAccepted)
+}
+
+// ReplayAll dead results, the ones with the rejected postback token are skipped
+func (h *DeliveryHandler) ReplayAll(w http.ResponseWriter, r *http.Request) {
+	tasks := make([]task.Task, 0)
+	for _, t := range h.tasks.ListDeliveries(postback.DeliveryDead) {
+		if t.Result == nil || t.Delivery.TokenRejected() {
+			continue
+		}
+		h.reporter.Report(t.Submission, *t.Result)
+		tasks = append(tasks, t)
+	}
+
+	httputil.WriteResponse(w, &ListDeliveriesResponse{Tasks: tasks}, http.StatusAccepted)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"errors"
+	"grader/internal/app/grader/runner"
+	"grader/internal/pkg/graderapi"
+	"grader/pkg/apperr"
+	"grader/pkg/httpserver"
+	"grader/pkg/httputil"
+	"grader/pkg/logger"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// lateWriteTimeout of the response written after the pulls
+const lateWriteTimeout = 10 * time.Second
+
+type ImageHandler struct {
+	policy   runner.ImagePolicy
+	executor runner.Executor
+}
+
+func NewImageHandler(policy runner.ImagePolicy, exec runner.Executor) *ImageHandler {
+	return &ImageHandler{policy: policy, executor: exec}
+}
+
+// Resolve the allowed image to its digest, submissions are accepted for the pinned images only
+func (h *ImageHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	in := &graderapi.ResolveImageRequest{}
+
+	if err := httputil.ReadBody(r, in); err != nil {
+		httputil.WriteError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if !httputil.ValidateData(w, in) {
+		return
+	}
+
+	image, err := runner.ResolveImage(ctx, h.executor, h.policy, in.Image)
+	if err != nil {
+		if errors.Is(err, apperr.ErrInvalidInput) {
+			httputil.WriteError(w, err, http.StatusBadRequest)
+			return
+		}
+		l := logger.Ctx(ctx)
+		l.Error().Err(err).Str("container_image", in.Image).Msg("Unable to resolve image")
+		// registry failures are shown as is to fix the image name
+		httputil.WriteError(w, err, http.StatusBadGateway)
+		return
+	}
+
+	// pull may take longer than the server write timeout
+	_ = httpserver.ExtendWriteDeadline(r, lateWriteTimeout)
+
+	out := &graderapi.ResolveImageResponse{
+		Image:  image,
+		Digest: image[strings.LastIndex(image, "@")+1:],
+	}
+
+	httputil.WriteResponse(w, out, http.StatusOK)
+}
+
+// Prewarm pulls the missing images, failures are reported per image
+func (h *ImageHandler) Prewarm(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	in := &graderapi.PrewarmImagesRequest{}
+
+	if err := httputil.ReadBody(r, in); err != nil {
+		httputil.WriteError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if !httputil.ValidateData(w, in) {
+		return
+	}
+
+	results := runner.PrewarmImages(ctx, h.executor, h.policy, in.Images)
+
+	// pulls may take longer than the server write timeout
+	_ = httpserver.ExtendWriteDeadline(r, lateWriteTimeout)
+
+	httputil.WriteResponse(w, &graderapi.PrewarmImagesResponse{Images: results}, http.StatusOK)
+}
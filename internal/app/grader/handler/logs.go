@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"grader/internal/app/grader/runner"
+	"grader/pkg/apperr"
+	"grader/pkg/httpserver"
+	"grader/pkg/httputil"
+	"grader/pkg/logger"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// streamWriteTimeout of every write to the event stream
+const streamWriteTimeout = 30 * time.Second
+
+// Logs streams output of the task containers as Server-Sent Events, the stream ends with the done event
+func (h *SubmissionHandler) Logs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	id, err := uuid.Parse(chi.URLParam(r, "task_id"))
+	if err != nil {
+		httputil.WriteError(w, fmt.Errorf("task id: %w", apperr.ErrInvalidInput), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.tasks.Get(id); err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			httputil.WriteError(w, err, http.StatusNotFound)
+			return
+		}
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	ew := &eventWriter{w: w, r: r, flusher: flusher}
+
+	err = h.executor.FollowLogs(ctx, id, ew, func() bool {
+		// the executor is following the task, so the stream is started while it waits for the output
+		ew.start()
+		t, err := h.tasks.Get(id)
+		return err != nil || t.Finished()
+	})
+	if errors.Is(err, runner.ErrNotSupported) && !ew.started {
+		httputil.WriteError(w, err, http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			l.Error().Err(err).Str("task_id", id.String()).Msg("Unable to follow logs")
+		}
+		return
+	}
+	ew.start()
+	ew.extendDeadline()
+	ew.flushLine()
+
+	status := ""
+	if t, err := h.tasks.Get(id); err == nil {
+		status = string(t.Status)
+	}
+	_, _ = fmt.Fprintf(w, "event: done\ndata: %s\n\n", status)
+	flusher.Flush()
+}
+
+// eventWriter sends every complete line of the output as a message event,
+// the stream is started on demand, so unsupported logs are still reported by the status
+type eventWriter struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	flusher http.Flusher
+	buf     bytes.Buffer
+	started bool
+}
+
+// start the event stream unless it is started already
+func (e *eventWriter) start() {
+	if e.started {
+		return
+	}
+	e.started = true
+	e.extendDeadline()
+
+	e.w.Header().Set("Content-Type", "text/event-stream")
+	e.w.Header().Set("Cache-Control", "no-cache")
+	e.w.Header().Set("X-Accel-Buffering", "no")
+	e.w.WriteHeader(http.StatusOK)
+	e.flusher.Flush()
+}
+
+func (e *eventWriter) Write(p []byte) (int, error) {
+	e.start()
+	e.extendDeadline()
+	e.buf.Write(p)
+
+	for {
+		i := bytes.IndexByte(e.buf.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := string(e.buf.Next(i + 1))
+		if err := e.send(line); err != nil {
+			return 0, err
+		}
+	}
+	e.flusher.Flush()
+
+	return len(p), nil
+}
+
+// extendDeadline of the stream write, the stream outlives the server write timeout,
+// but a client not reading it is still cut off
+func (e *eventWriter) extendDeadline() {
+	_ = httpserver.ExtendWriteDeadline(e.r, streamWriteTimeout)
+}
+
+// flushLine left without the line ending
+func (e *eventWriter) flushLine() {
+	if e.buf.Len() > 0 {
+		_ = e.send(e.buf.String())
+		e.buf.Reset()
+	}
+}
+
+func (e *eventWriter) send(line string) error {
+	line = strings.TrimRight(line, "\r\n")
+	_, err := fmt.Fprintf(e.w, "data: %s\n\n", line)
+	return err
+}
@@ -1,29 +1,70 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"grader/internal/app/grader/runner"
+	"grader/internal/app/grader/task"
+	"grader/internal/pkg/graderapi"
+	"grader/pkg/apperr"
 	"grader/pkg/httputil"
+	"grader/pkg/logger"
 	"grader/pkg/workerpool"
 	"net/http"
+	"strconv"
+	"time"
 )
 
+// defaultRetryAfter suggested to the clients when the grader is busy
+const defaultRetryAfter = 30 * time.Second
+
 type SubmissionHandler struct {
-	workers *workerpool.Pool
+	workers    *workerpool.Pool
+	config     runner.Config
+	executor   runner.Executor
+	tasks      *task.Registry
+	reporter   runner.Reporter
+	retryAfter time.Duration
 }
 
-func NewSubmissionHandler(wp *workerpool.Pool) *SubmissionHandler {
-	return &SubmissionHandler{
-		workers: wp,
+type SubmissionHandlerOption func(*SubmissionHandler)
+
+func WithRetryAfter(v time.Duration) SubmissionHandlerOption {
+	return func(h *SubmissionHandler) {
+		if v > 0 {
+			h.retryAfter = v
+		}
 	}
 }
 
-type CheckSubmissionRequest struct {
-	Submission runner.Submission `json:"submission"`
+func NewSubmissionHandler(
+	wp *workerpool.Pool,
+	cfg runner.Config,
+	exec runner.Executor,
+	tasks *task.Registry,
+	reporter runner.Reporter,
+	opts ...SubmissionHandlerOption,
+) *SubmissionHandler {
+	h := &SubmissionHandler{
+		workers:    wp,
+		config:     cfg,
+		executor:   exec,
+		tasks:      tasks,
+		reporter:   reporter,
+		retryAfter: defaultRetryAfter,
+	}
+
+	for _, o := range opts {
+		o(h)
+	}
+
+	return h
 }
 
-type CheckSubmissionResponse struct {
-	TaskID uuid.UUID `json:"task_id"`
+type ListSubmissionsResponse struct {
+	Tasks []task.Task `json:"tasks"`
 }
 
 /**
@@ -43,7 +84,7 @@ func (h *SubmissionHandler) Check(w http.ResponseWriter, r *http.Request) {
 	//ctx := r.Context()
 	//l := logger.Ctx(ctx)
 
-	in := &CheckSubmissionRequest{}
+	in := &graderapi.CheckSubmissionRequest{}
 
 	if err := httputil.ReadBody(r, in); err != nil {
 		httputil.WriteError(w, err, http.StatusBadRequest)
@@ -54,13 +95,163 @@ func (h *SubmissionHandler) Check(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	in.Submission.TaskID = uuid.New()
+	sub := submission(in.Submission)
+
+	if err := h.config.Images.Check(sub.ContainerImage); err != nil {
+		httputil.WriteError(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := sub.CheckFiles(); err != nil {
+		httputil.WriteError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	sub.TaskID = uuid.New()
+
+	t, created, err := h.tasks.AddIdempotent(in.IdempotencyKey, sub)
+	if err != nil {
+		l := logger.Ctx(r.Context())
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+	if !created {
+		// redelivered request, the task is already there
+		httputil.WriteResponse(w, &graderapi.CheckSubmissionResponse{TaskID: t.ID}, http.StatusOK)
+		return
+	}
+
+	if err := h.workers.TrySubmit(runner.CheckSubmissionJob(h.executor, h.config, sub, h.tasks, h.reporter)); err != nil {
+		h.tasks.Remove(sub.TaskID)
 
-	h.workers.Run(runner.CheckSubmissionJob(in.Submission))
+		// client is expected to keep the submission and try again later
+		w.Header().Set("Retry-After", strconv.Itoa(int(h.retryAfter.Seconds())))
+		status := http.StatusServiceUnavailable
+		if errors.Is(err, workerpool.ErrQueueFull) {
+			status = http.StatusTooManyRequests
+		}
+		httputil.WriteError(w, err, status)
+		return
+	}
 
-	out := &CheckSubmissionResponse{
-		TaskID: in.Submission.TaskID,
+	out := &graderapi.CheckSubmissionResponse{
+		TaskID: sub.TaskID,
 	}
 
 	httputil.WriteResponse(w, out, http.StatusAccepted)
 }
+
+// Read the task state and its result once it is done
+func (h *SubmissionHandler) Read(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "task_id"))
+	if err != nil {
+		httputil.WriteError(w, fmt.Errorf("task id: %w", apperr.ErrInvalidInput), http.StatusBadRequest)
+		return
+	}
+
+	t, err := h.tasks.Get(id)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			httputil.WriteError(w, err, http.StatusNotFound)
+			return
+		}
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	httputil.WriteResponse(w, t, http.StatusOK)
+}
+
+// List tasks, optionally filtered by status
+func (h *SubmissionHandler) List(w http.ResponseWriter, r *http.Request) {
+	status := task.Status(r.URL.Query().Get("status"))
+	switch status {
+	case "", task.StatusQueued, task.StatusRunning, task.StatusDone, task.StatusFailed, task.StatusCancelled:
+	default:
+		httputil.WriteError(w, fmt.Errorf("status: %w", apperr.ErrInvalidInput), http.StatusBadRequest)
+		return
+	}
+
+	out := &ListSubmissionsResponse{
+		Tasks: h.tasks.List(status),
+	}
+
+	httputil.WriteResponse(w, out, http.StatusOK)
+}
+
+// Cancel the task, the running container is killed and the cancelled result is sent to the postback URL
+func (h *SubmissionHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	id, err := uuid.Parse(chi.URLParam(r, "task_id"))
+	if err != nil {
+		httputil.WriteError(w, fmt.Errorf("task id: %w", apperr.ErrInvalidInput), http.StatusBadRequest)
+		return
+	}
+
+	t, err := h.tasks.Cancel(id)
+	switch {
+	case err == nil:
+		// all is ok
+	case errors.Is(err, apperr.ErrNotFound):
+		httputil.WriteError(w, err, http.StatusNotFound)
+	case errors.Is(err, apperr.ErrConflict):
+		httputil.WriteError(w, fmt.Errorf("task is %s: %w", t.Status, err), http.StatusConflict)
+	default:
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+	}
+	if err != nil {
+		return
+	}
+
+	// waiting task never reaches its job, running one reports by itself
+	if t.Status == task.StatusCancelled {
+		h.reporter.Report(t.Submission, *t.Result)
+	}
+
+	httputil.WriteResponse(w, t, http.StatusAccepted)
+}
+
+// submission of the request to be run
+func submission(in graderapi.Submission) runner.Submission {
+	out := runner.Submission{
+		ContainerImage: in.ContainerImage,
+		PartID:         in.PartID,
+		PostbackURL:    in.PostbackURL,
+		PostbackToken:  in.PostbackToken,
+		ResultMode:     in.ResultMode,
+	}
+
+	for _, f := range in.Files {
+		out.Files = append(out.Files, runner.SubmissionFile{Name: f.Name, URL: f.URL})
+	}
+
+	if in.Sandbox != nil {
+		out.Sandbox = &runner.Sandbox{
+			NetworkMode:    in.Sandbox.NetworkMode,
+			User:           in.Sandbox.User,
+			MemoryMB:       in.Sandbox.MemoryMB,
+			CPUs:           in.Sandbox.CPUs,
+			PidsLimit:      in.Sandbox.PidsLimit,
+			TmpfsMB:        in.Sandbox.TmpfsMB,
+			ReadOnlyRootfs: in.Sandbox.ReadOnlyRootfs,
+			Timeout:        in.Sandbox.Timeout,
+		}
+	}
+
+	for _, tc := range in.TestCases {
+		out.TestCases = append(out.TestCases, runner.TestCase{
+			Name:      tc.Name,
+			Input:     tc.Input,
+			Expected:  tc.Expected,
+			Compare:   tc.Compare,
+			Tolerance: tc.Tolerance,
+			Weight:    tc.Weight,
+			Hidden:    tc.Hidden,
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,15 @@
+package postback
+
+import "time"
+
+type Config struct {
+	// Timeout of a single delivery attempt
+	Timeout time.Duration `mapstructure:"timeout"`
+	// InitialInterval between the first attempts, doubled after each failure
+	InitialInterval time.Duration `mapstructure:"initial_interval"`
+	// MaxInterval between the attempts
+	MaxInterval time.Duration `mapstructure:"max_interval"`
+	// MaxPeriod of retries after which the result is dead, it must stay within the postback token lifetime
+	// or the retries end up rejected as unauthorized
+	MaxPeriod time.Duration `mapstructure:"max_period"`
+}
@@ -0,0 +1,190 @@
+package postback
+
+import (
+	"context"
+	"errors"
+	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
+	"grader/internal/app/grader/runner"
+	"grader/pkg/logger"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryDead      DeliveryStatus = "dead"
+)
+
+// Delivery state of the result postback
+type Delivery struct {
+	Status        DeliveryStatus `json:"status"`
+	Attempts      int            `json:"attempts"`
+	LastAttemptAt *time.Time     `json:"last_attempt_at,omitempty"`
+	LastError     string         `json:"last_error,omitempty"`
+	// LastStatusCode of the receiver response to the last attempt, zero if there was none
+	LastStatusCode int `json:"last_status_code,omitempty"`
+}
+
+// Undelivered reports if the result still has to reach the receiver
+func (d *Delivery) Undelivered() bool {
+	return d != nil && d.Status != DeliveryDelivered
+}
+
+// TokenRejected reports if the receiver refused the postback token, e.g. an expired one,
+// replaying the result with it is pointless, the submission has to be checked again
+func (d *Delivery) TokenRejected() bool {
+	return d != nil && d.LastStatusCode == http.StatusUnauthorized
+}
+
+// Tracker of the delivery state changes, it is expected to persist them
+type Tracker interface {
+	DeliveryUpdated(taskID uuid.UUID, d Delivery)
+}
+
+// runner.Reporter interface implementation
+var _ runner.Reporter = (*Outbox)(nil)
+
+// Outbox delivers results in background retrying failures with exponential backoff and jitter
+type Outbox struct {
+	config  Config
+	client  *resty.Client
+	tracker Tracker
+	logger  logger.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+const (
+	defaultTimeout         = 10 * time.Second
+	defaultInitialInterval = time.Second
+	defaultMaxInterval     = 5 * time.Minute
+	defaultMaxPeriod       = 6 * time.Hour
+)
+
+func NewOutbox(cfg Config, tracker Tracker) *Outbox {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.InitialInterval <= 0 {
+		cfg.InitialInterval = defaultInitialInterval
+	}
+	if cfg.MaxInterval < cfg.InitialInterval {
+		cfg.MaxInterval = defaultMaxInterval
+	}
+	if cfg.MaxPeriod <= 0 {
+		cfg.MaxPeriod = defaultMaxPeriod
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Outbox{
+		config:  cfg,
+		client:  resty.New(),
+		tracker: tracker,
+		logger:  logger.Global().WithComponent("Outbox"),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Report implementation of runner.Reporter
+func (o *Outbox) Report(submission runner.Submission, result runner.SubmissionResult) {
+	d := Delivery{Status: DeliveryPending}
+	o.tracker.DeliveryUpdated(submission.TaskID, d)
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		o.deliver(submission, result, d)
+	}()
+}
+
+// Stop delivering, pending results stay pending to be resumed on start
+func (o *Outbox) Stop() {
+	o.cancel()
+	o.wg.Wait()
+}
+
+func (o *Outbox) deliver(submission runner.Submission, result runner.SubmissionResult, d Delivery) {
+	l := o.logger.With().Str("task_id", submission.TaskID.String()).Logger()
+
+	started := time.Now()
+	interval := o.config.InitialInterval
+
+	for {
+		err := o.attempt(submission, result)
+
+		now := time.Now()
+		d.Attempts++
+		d.LastAttemptAt = &now
+		d.LastError = ""
+		d.LastStatusCode = 0
+
+		if err == nil {
+			d.Status = DeliveryDelivered
+			o.tracker.DeliveryUpdated(submission.TaskID, d)
+			l.Debug().Int("attempts", d.Attempts).Msg("Result delivered")
+			return
+		}
+		d.LastError = err.Error()
+
+		var pe *runner.PostbackError
+		if errors.As(err, &pe) {
+			d.LastStatusCode = pe.StatusCode
+		}
+		if d.TokenRejected() {
+			d.LastError = "postback token is rejected, it is likely expired: " + d.LastError
+		}
+
+		if o.ctx.Err() != nil {
+			// stopping, the failure is caused by the cancellation
+			o.tracker.DeliveryUpdated(submission.TaskID, d)
+			return
+		}
+
+		if (pe != nil && pe.Permanent()) || now.Add(interval).Sub(started) > o.config.MaxPeriod {
+			d.Status = DeliveryDead
+			o.tracker.DeliveryUpdated(submission.TaskID, d)
+			l.Error().Err(err).Int("attempts", d.Attempts).Msg("Result is dead")
+			return
+		}
+
+		o.tracker.DeliveryUpdated(submission.TaskID, d)
+		l.Warn().Err(err).Int("attempts", d.Attempts).Msg("Result delivery failed")
+
+		select {
+		case <-time.After(jitter(interval)):
+		case <-o.ctx.Done():
+			return
+		}
+
+		interval *= 2
+		if interval > o.config.MaxInterval {
+			interval = o.config.MaxInterval
+		}
+	}
+}
+
+func (o *Outbox) attempt(submission runner.Submission, result runner.SubmissionResult) error {
+	ctx, cancel := context.WithTimeout(o.ctx, o.config.Timeout)
+	defer cancel()
+
+	return runner.SendResult(ctx, o.client, submission, result)
+}
+
+// jitter spreads the retries of results failed at once over the upper half of the interval
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
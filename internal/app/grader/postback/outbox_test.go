@@ -0,0 +1,108 @@
+package postback
+
+import (
+	"github.com/google/uuid"
+	"grader/internal/app/grader/runner"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type trackerStub struct {
+	mu         sync.Mutex
+	deliveries []Delivery
+}
+
+func (t *trackerStub) DeliveryUpdated(_ uuid.UUID, d Delivery) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.deliveries = append(t.deliveries, d)
+}
+
+func (t *trackerStub) last() Delivery {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.deliveries[len(t.deliveries)-1]
+}
+
+func TestOutbox_Report(t *testing.T) {
+	tests := []struct {
+		name         string
+		codes        []int
+		maxPeriod    time.Duration
+		wantStatus   DeliveryStatus
+		wantAttempts int
+		wantRejected bool
+	}{
+		{
+			name:         "delivered after failures",
+			codes:        []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusNoContent},
+			maxPeriod:    time.Minute,
+			wantStatus:   DeliveryDelivered,
+			wantAttempts: 3,
+		},
+		{
+			name:         "rejected",
+			codes:        []int{http.StatusConflict},
+			maxPeriod:    time.Minute,
+			wantStatus:   DeliveryDead,
+			wantAttempts: 1,
+		},
+		{
+			name:         "token rejected",
+			codes:        []int{http.StatusUnauthorized},
+			maxPeriod:    time.Minute,
+			wantStatus:   DeliveryDead,
+			wantAttempts: 1,
+			wantRejected: true,
+		},
+		{
+			name:         "default max period",
+			codes:        []int{http.StatusInternalServerError, http.StatusNoContent},
+			wantStatus:   DeliveryDelivered,
+			wantAttempts: 2,
+		},
+		{
+			name:         "retries exhausted",
+			codes:        []int{http.StatusServiceUnavailable},
+			maxPeriod:    time.Millisecond,
+			wantStatus:   DeliveryDead,
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(atomic.AddInt32(&calls, 1)) - 1
+				if n >= len(tt.codes) {
+					n = len(tt.codes) - 1
+				}
+				w.WriteHeader(tt.codes[n])
+			}))
+			defer srv.Close()
+
+			tracker := &trackerStub{}
+			o := NewOutbox(Config{
+				Timeout:         time.Second,
+				InitialInterval: 10 * time.Millisecond,
+				MaxInterval:     20 * time.Millisecond,
+				MaxPeriod:       tt.maxPeriod,
+			}, tracker)
+
+			o.Report(runner.Submission{TaskID: uuid.New(), PostbackURL: srv.URL}, runner.SubmissionResult{})
+			o.wg.Wait()
+
+			got := tracker.last()
+			if got.Status != tt.wantStatus || got.Attempts != tt.wantAttempts {
+				t.Errorf("Report() delivery = %+v, want %s after %d attempts", got, tt.wantStatus, tt.wantAttempts)
+			}
+			if got.TokenRejected() != tt.wantRejected {
+				t.Errorf("Report() token rejected = %v, want %v", got.TokenRejected(), tt.wantRejected)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/google/uuid"
+	"grader/pkg/logger"
+)
+
+// containerNamePrefix of every container started by the grader
+const containerNamePrefix = "grader_"
+
+const (
+	// labelInstance of the grader the container is started by
+	labelInstance = "grader.instance"
+	// labelTask the container is run for
+	labelTask = "grader.task"
+)
+
+func containerName(taskID uuid.UUID) string {
+	return containerNamePrefix + taskID.String()
+}
+
+func containerLabels(instance string, taskID uuid.UUID) map[string]string {
+	return map[string]string{
+		labelInstance: instance,
+		labelTask:     taskID.String(),
+	}
+}
+
+// removeContainer with its volumes, the job context may be already done at this point
+func removeContainer(l logger.Logger, cli *client.Client, containerID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+
+	err := cli.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{
+		RemoveVolumes: true,
+		Force:         true,
+	})
+	if err != nil && !client.IsErrNotFound(err) {
+		l.Error().Err(err).Str("container_id", containerID).Msg("Unable to remove container")
+		return
+	}
+
+	l.Debug().Str("container_id", containerID).Msg("Container removed")
+}
+
+// Containers implementation of Executor, the ones labelled with the instance
+func (e *DockerExecutor) Containers(ctx context.Context) ([]Container, error) {
+	list, err := e.cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", labelInstance+"="+e.instance)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("container list: %w", err)
+	}
+
+	out := make([]Container, 0, len(list))
+	for _, c := range list {
+		// task is unknown if the label is broken, the container is an orphan anyway
+		taskID, _ := uuid.Parse(c.Labels[labelTask])
+		out = append(out, Container{ID: c.ID, TaskID: taskID})
+	}
+
+	return out, nil
+}
+
+// RemoveContainer implementation of Executor
+func (e *DockerExecutor) RemoveContainer(ctx context.Context, id string) error {
+	err := e.cli.ContainerRemove(ctx, id, types.ContainerRemoveOptions{
+		RemoveVolumes: true,
+		Force:         true,
+	})
+	if err != nil && !client.IsErrNotFound(err) {
+		return fmt.Errorf("container remove: %w", err)
+	}
+	return nil
+}
+
+// RemoveOrphans of the executor instance left by a previous grader run, containers of the kept tasks stay,
+// returns number of removed containers
+func RemoveOrphans(ctx context.Context, exec Executor, keep func(taskID uuid.UUID) bool) (int, error) {
+	l := logger.Global().WithComponent("RemoveOrphans")
+
+	list, err := exec.Containers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, c := range list {
+		if keep(c.TaskID) {
+			continue
+		}
+		if err := exec.RemoveContainer(ctx, c.ID); err != nil {
+			l.Error().Err(err).Str("container_id", c.ID).Msg("Unable to remove container")
+			continue
+		}
+		n++
+	}
+
+	return n, nil
+}
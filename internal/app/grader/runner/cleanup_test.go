@@ -0,0 +1,44 @@
+package runner
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"reflect"
+	"testing"
+)
+
+func TestRemoveOrphans(t *testing.T) {
+	running := uuid.New()
+	interrupted := uuid.New()
+
+	exec := &FakeExecutor{Stale: []Container{
+		{ID: "running", TaskID: running},
+		{ID: "interrupted", TaskID: interrupted},
+		{ID: "unlabelled", TaskID: uuid.Nil},
+	}}
+
+	n, err := RemoveOrphans(context.Background(), exec, func(id uuid.UUID) bool {
+		return id == running
+	})
+	if err != nil {
+		t.Fatalf("RemoveOrphans() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("RemoveOrphans() = %d, want 2", n)
+	}
+
+	left, _ := exec.Containers(context.Background())
+	if want := []Container{{ID: "running", TaskID: running}}; !reflect.DeepEqual(left, want) {
+		t.Errorf("containers left = %+v, want %+v", left, want)
+	}
+}
+
+func TestContainerLabels(t *testing.T) {
+	taskID := uuid.New()
+
+	labels := containerLabels("grader-1", taskID)
+
+	if labels[labelInstance] != "grader-1" || labels[labelTask] != taskID.String() {
+		t.Errorf("containerLabels() = %v", labels)
+	}
+}
@@ -0,0 +1,16 @@
+package runner
+
+type Config struct {
+	// Executor of the grading command: docker or local
+	Executor string `mapstructure:"executor"`
+	// Local executor settings
+	Local LocalConfig `mapstructure:"local"`
+	// Sandbox defaults, assessment provided values take precedence
+	Sandbox Sandbox `mapstructure:"sandbox"`
+	// Images allowed to be run
+	Images ImagePolicy `mapstructure:"images"`
+	// PullPolicy of the grading images: always, if_not_present or never
+	PullPolicy string `mapstructure:"pull_policy"`
+	// Instance name unique among the graders sharing the docker host, the hostname if empty
+	Instance string `mapstructure:"instance"`
+}
@@ -0,0 +1,283 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"grader/pkg/apperr"
+	"grader/pkg/logger"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// submissionMountDir where the submission files are available inside the container
+const submissionMountDir = "/app/submission"
+
+// DockerExecutor runs the grading image in a sandboxed container
+type DockerExecutor struct {
+	cli        *client.Client
+	pullPolicy string
+	// instance label of the containers, graders sharing the docker host tell their containers apart by it
+	instance string
+}
+
+// NewDockerExecutor labelling containers with the instance, the hostname if empty
+func NewDockerExecutor(pullPolicy string, instance string) (*DockerExecutor, error) {
+	switch pullPolicy {
+	case PullAlways, PullIfNotPresent, PullNever:
+	default:
+		return nil, fmt.Errorf("unknown pull policy %q", pullPolicy)
+	}
+
+	if instance == "" {
+		var err error
+		if instance, err = os.Hostname(); err != nil {
+			return nil, fmt.Errorf("hostname: %w", err)
+		}
+	}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("new client: %w", err)
+	}
+
+	return &DockerExecutor{cli: cli, pullPolicy: pullPolicy, instance: instance}, nil
+}
+
+// Prepare implementation of Executor, the image is pulled according to the pull policy
+func (e *DockerExecutor) Prepare(ctx context.Context, submission Submission) (*Workspace, error) {
+	l := logger.Global().WithComponent("DockerExecutor")
+
+	if err := ensureImage(ctx, l, e.cli, e.pullPolicy, submission.ContainerImage); err != nil {
+		return nil, err
+	}
+
+	return newWorkspace(submission)
+}
+
+// Run implementation of Executor
+func (e *DockerExecutor) Run(ctx context.Context, ws *Workspace, spec RunSpec) (*ContainerOutput, error) {
+	l := logger.Global().WithComponent("DockerExecutor")
+
+	binds := []string{fmt.Sprintf("%s:%s", ws.SubmissionDir, submissionMountDir)}
+	if spec.Result {
+		binds = append(binds, fmt.Sprintf("%s:%s", ws.ResultDir, resultMountDir))
+	}
+
+	return runContainer(ctx, l, e.cli, ws.Image, spec, binds, containerLabels(e.instance, ws.TaskID))
+}
+
+// Cleanup implementation of Executor
+func (e *DockerExecutor) Cleanup(ws *Workspace) {
+	removeWorkspace(ws)
+}
+
+// Close the docker client
+func (e *DockerExecutor) Close() error {
+	return e.cli.Close()
+}
+
+func runContainer(
+	ctx context.Context,
+	l logger.Logger,
+	cli *client.Client,
+	image string,
+	spec RunSpec,
+	binds []string,
+	labels map[string]string,
+) (*ContainerOutput, error) {
+	tty := spec.Stdin == nil
+
+	l.Debug().Str("container_image", image).Msg("Creating container")
+	resp, err := cli.ContainerCreate(ctx, &container.Config{
+		Image:        image,
+		Cmd:          spec.Cmd,
+		Env:          sandboxEnv,
+		User:         spec.Sandbox.User,
+		Tty:          tty,
+		OpenStdin:    !tty,
+		StdinOnce:    !tty,
+		AttachStdin:  !tty,
+		AttachStdout: true,
+		AttachStderr: true,
+		Labels:       labels,
+	}, spec.Sandbox.HostConfig(binds), nil, nil, spec.Name)
+	if err != nil {
+		return nil, fmt.Errorf("container create: %w", err)
+	}
+	// deferred to cover failures, timeouts and panics alike
+	defer removeContainer(l, cli, resp.ID)
+
+	if spec.Stdin != nil {
+		l.Debug().Str("container_id", resp.ID).Msg("Attaching stdin")
+		hj, err := cli.ContainerAttach(ctx, resp.ID, types.ContainerAttachOptions{
+			Stream: true,
+			Stdin:  true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("container attach: %w", err)
+		}
+		defer hj.Close()
+
+		// input is small enough to fit the socket buffers until the container reads it
+		go func() {
+			_, _ = io.Copy(hj.Conn, strings.NewReader(*spec.Stdin))
+			_ = hj.CloseWrite()
+		}()
+	}
+
+	l.Debug().
+		Str("container_image", image).
+		Str("container_id", resp.ID).
+		Msg("Starting container")
+	if err := cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
+		return nil, fmt.Errorf("container start: %w", err)
+	}
+
+	runCtx := ctx
+	if spec.Sandbox.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, time.Duration(spec.Sandbox.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	l.Debug().
+		Str("container_image", image).
+		Str("container_id", resp.ID).
+		Msg("Waiting for container to finish")
+	statusCh, errCh := cli.ContainerWait(runCtx, resp.ID, container.WaitConditionNotRunning)
+
+	var s container.ContainerWaitOKBody
+
+	select {
+	case err := <-errCh:
+		if err != nil && runCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			l.Debug().
+				Str("container_image", image).
+				Str("container_id", resp.ID).
+				Int64("timeout", spec.Sandbox.Timeout).
+				Msg("Container timed out")
+			return nil, stopTimedOut(cli, resp.ID, tty, spec.Sandbox.Timeout)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("container finish: %w", err)
+		}
+	case s = <-statusCh:
+		l.Debug().
+			Str("container_image", image).
+			Str("container_id", resp.ID).
+			Int64("container_status", s.StatusCode).
+			Msg("Container finished")
+	}
+
+	l.Debug().
+		Str("container_image", image).
+		Str("container_id", resp.ID).
+		Msg("Reading logs")
+	stdout, stderr, err := containerLogs(ctx, cli, resp.ID, tty)
+	if err != nil {
+		return nil, fmt.Errorf("container out: %w", err)
+	}
+
+	return &ContainerOutput{Output: stdout, Stderr: stderr, StatusCode: s.StatusCode}, nil
+}
+
+// stopTimedOut kills the container, the job context is not used as its deadline is already gone
+func stopTimedOut(cli *client.Client, containerID string, tty bool, timeout int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+
+	if err := cli.ContainerKill(ctx, containerID, "KILL"); err != nil {
+		return fmt.Errorf("container kill: %w", err)
+	}
+
+	// partial output is still useful to figure out where it hangs
+	stdout, stderr, err := containerLogs(ctx, cli, containerID, tty)
+	if err != nil {
+		return fmt.Errorf("container out: %w", err)
+	}
+
+	return TimeoutError{Output: stdout + stderr, Timeout: time.Duration(timeout) * time.Second}
+}
+
+// containerLogs split into stdout and stderr, TTY output goes to stdout only
+func containerLogs(ctx context.Context, cli *client.Client, containerID string, tty bool) (string, string, error) {
+	out, err := cli.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: false,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("container logs: %w", err)
+	}
+	defer func(logs io.ReadCloser) {
+		_ = logs.Close()
+	}(out)
+
+	stdout := new(strings.Builder)
+	stderr := new(strings.Builder)
+	src := io.LimitReader(out, maxOutputSize)
+
+	if tty {
+		_, err = io.Copy(stdout, src)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, src)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("logs: %w", err)
+	}
+
+	return stdout.String(), stderr.String(), nil
+}
+
+// ResolveImage implementation of Executor, the tag is pulled as it may be moved since the last pull
+func (e *DockerExecutor) ResolveImage(ctx context.Context, image string) (string, error) {
+	l := logger.Global().WithComponent("DockerExecutor")
+
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v: %w", image, err, apperr.ErrInvalidInput)
+	}
+
+	if err := pullImage(ctx, l, e.cli, image); err != nil {
+		return "", err
+	}
+
+	inspect, _, err := e.cli.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return "", fmt.Errorf("image inspect: %w", err)
+	}
+
+	for _, rd := range inspect.RepoDigests {
+		ref, err := reference.ParseNormalizedNamed(rd)
+		if err != nil || ref.Name() != named.Name() {
+			continue
+		}
+		c, ok := ref.(reference.Canonical)
+		if !ok {
+			continue
+		}
+
+		pinned, err := reference.WithDigest(named, c.Digest())
+		if err != nil {
+			return "", fmt.Errorf("with digest: %w", err)
+		}
+		return pinned.String(), nil
+	}
+
+	// locally built images have no registry digest to be pinned to
+	return "", fmt.Errorf("%s: no repository digest: %w", image, apperr.ErrInvalidInput)
+}
+
+// PrewarmImage implementation of Executor, the image is pulled if it is missing
+func (e *DockerExecutor) PrewarmImage(ctx context.Context, image string) error {
+	l := logger.Global().WithComponent("DockerExecutor")
+
+	return ensureImage(ctx, l, e.cli, PullIfNotPresent, image)
+}
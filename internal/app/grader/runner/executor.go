@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+const (
+	// ExecutorDocker runs the grading image in a sandboxed container
+	ExecutorDocker = "docker"
+	// ExecutorLocal runs the grading command on the host with rlimits, no isolation is provided
+	ExecutorLocal = "local"
+)
+
+// ErrNotSupported by the executor, it is returned before anything is done
+var ErrNotSupported = errors.New("not supported by the executor")
+
+// Executor runs the grading command of the submission
+type Executor interface {
+	// Prepare the workspace of the task and the image to run
+	Prepare(ctx context.Context, submission Submission) (*Workspace, error)
+	// Run the grading command in the workspace within the sandbox limits,
+	// TimeoutError is returned when it runs out of time
+	Run(ctx context.Context, ws *Workspace, spec RunSpec) (*ContainerOutput, error)
+	// Cleanup the workspace once the task is done
+	Cleanup(ws *Workspace)
+	// ResolveImage of the tag to the reference pinned to its digest
+	ResolveImage(ctx context.Context, image string) (string, error)
+	// PrewarmImage so the submissions do not wait for it
+	PrewarmImage(ctx context.Context, image string) error
+	// FollowLogs of the task runs as they appear until the task is finished
+	FollowLogs(ctx context.Context, taskID uuid.UUID, w io.Writer, finished func() bool) error
+	// Containers started by this executor instance, including the ones of the previous runs
+	Containers(ctx context.Context) ([]Container, error)
+	// RemoveContainer started by this executor instance
+	RemoveContainer(ctx context.Context, id string) error
+}
+
+// Container of the task run
+type Container struct {
+	ID     string
+	TaskID uuid.UUID
+}
+
+// Workspace of the task shared by its runs
+type Workspace struct {
+	TaskID uuid.UUID
+	Image  string
+	// SubmissionDir the submission files are fetched to
+	SubmissionDir string
+	// ResultDir the verdict file may be written to
+	ResultDir string
+}
+
+// RunSpec of a single run of the grading command
+type RunSpec struct {
+	// Name of the run, unique across the tasks
+	Name    string
+	Cmd     []string
+	Sandbox Sandbox
+	// Result dir is available to the run
+	Result bool
+	// Stdin fed to the run, the output streams are merged when it is nil
+	Stdin *string
+}
+
+// NewExecutor configured by the runner config
+func NewExecutor(cfg Config) (Executor, error) {
+	switch cfg.Executor {
+	case ExecutorDocker, "":
+		return NewDockerExecutor(cfg.PullPolicy, cfg.Instance)
+	case ExecutorLocal:
+		return NewLocalExecutor(cfg.Local)
+	default:
+		return nil, fmt.Errorf("unknown executor %q", cfg.Executor)
+	}
+}
+
+// newWorkspace in the temporary dirs, sandbox user is not the owner of them
+func newWorkspace(submission Submission) (*Workspace, error) {
+	ws := &Workspace{TaskID: submission.TaskID, Image: submission.ContainerImage}
+
+	var err error
+	ws.SubmissionDir, err = ioutil.TempDir("", "submission*")
+	if err != nil {
+		return nil, fmt.Errorf("temp dir: %w", err)
+	}
+	if err := os.Chmod(ws.SubmissionDir, 0755); err != nil {
+		removeWorkspace(ws)
+		return nil, fmt.Errorf("temp dir chmod: %w", err)
+	}
+
+	ws.ResultDir, err = ioutil.TempDir("", "result*")
+	if err != nil {
+		removeWorkspace(ws)
+		return nil, fmt.Errorf("result dir: %w", err)
+	}
+	// sandbox user has to be able to write the verdict
+	if err := os.Chmod(ws.ResultDir, 0777); err != nil {
+		removeWorkspace(ws)
+		return nil, fmt.Errorf("result dir chmod: %w", err)
+	}
+
+	return ws, nil
+}
+
+func removeWorkspace(ws *Workspace) {
+	if ws.SubmissionDir != "" {
+		_ = os.RemoveAll(ws.SubmissionDir)
+	}
+	if ws.ResultDir != "" {
+		_ = os.RemoveAll(ws.ResultDir)
+	}
+}
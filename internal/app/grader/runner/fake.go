@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"grader/pkg/apperr"
+	"io"
+	"sync"
+)
+
+// FakeExecutor runs nothing, every run is answered by RunFunc, meant for tests
+type FakeExecutor struct {
+	RunFunc func(ws *Workspace, spec RunSpec) (*ContainerOutput, error)
+	// Digests of the images by the tagged reference, other images have no digest
+	Digests map[string]string
+	// Logs written for every task
+	Logs string
+	// Stale containers of the instance left by the previous runs, removed ones are dropped
+	Stale []Container
+
+	mu      sync.Mutex
+	runs    []RunSpec
+	prewarm []string
+}
+
+// Prepare implementation of Executor
+func (e *FakeExecutor) Prepare(_ context.Context, submission Submission) (*Workspace, error) {
+	return newWorkspace(submission)
+}
+
+// Run implementation of Executor
+func (e *FakeExecutor) Run(_ context.Context, ws *Workspace, spec RunSpec) (*ContainerOutput, error) {
+	e.mu.Lock()
+	e.runs = append(e.runs, spec)
+	e.mu.Unlock()
+
+	if e.RunFunc == nil {
+		return &ContainerOutput{}, nil
+	}
+	return e.RunFunc(ws, spec)
+}
+
+// Cleanup implementation of Executor
+func (e *FakeExecutor) Cleanup(ws *Workspace) {
+	removeWorkspace(ws)
+}
+
+// ResolveImage implementation of Executor
+func (e *FakeExecutor) ResolveImage(_ context.Context, image string) (string, error) {
+	d, ok := e.Digests[image]
+	if !ok {
+		return "", fmt.Errorf("%s: no repository digest: %w", image, apperr.ErrInvalidInput)
+	}
+	return image + "@" + d, nil
+}
+
+// PrewarmImage implementation of Executor
+func (e *FakeExecutor) PrewarmImage(_ context.Context, image string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.prewarm = append(e.prewarm, image)
+	return nil
+}
+
+// FollowLogs implementation of Executor
+func (e *FakeExecutor) FollowLogs(_ context.Context, _ uuid.UUID, w io.Writer, _ func() bool) error {
+	_, err := io.WriteString(w, e.Logs)
+	return err
+}
+
+// Containers implementation of Executor
+func (e *FakeExecutor) Containers(_ context.Context) ([]Container, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]Container(nil), e.Stale...), nil
+}
+
+// RemoveContainer implementation of Executor
+func (e *FakeExecutor) RemoveContainer(_ context.Context, id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, c := range e.Stale {
+		if c.ID == id {
+			e.Stale = append(e.Stale[:i], e.Stale[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Prewarmed images so far
+func (e *FakeExecutor) Prewarmed() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]string(nil), e.prewarm...)
+}
+
+// Runs done so far
+func (e *FakeExecutor) Runs() []RunSpec {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return append([]RunSpec(nil), e.runs...)
+}
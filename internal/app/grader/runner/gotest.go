@@ -0,0 +1,213 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// go test -json actions
+const (
+	goTestPass   = "pass"
+	goTestFail   = "fail"
+	goTestSkip   = "skip"
+	goTestOutput = "output"
+)
+
+// maxTestOutput kept for a single test or package
+const maxTestOutput = 16 << 10
+
+var coverageRe = regexp.MustCompile(`coverage: ([0-9.]+)% of statements`)
+
+// goTestEvent of the test2json stream
+type goTestEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Elapsed float64 `json:"Elapsed"`
+	Output  string  `json:"Output"`
+}
+
+// goTestReport collected from the event stream, slices keep the order of appearance
+type goTestReport struct {
+	Tests    []TestResult
+	Packages []PackageResult
+	// Other lines which are not events, build errors usually
+	Other string
+
+	tests    map[string]int
+	packages map[string]int
+}
+
+// parseGoTest event stream, TTY line endings included
+func parseGoTest(output string) *goTestReport {
+	r := &goTestReport{
+		tests:    make(map[string]int),
+		packages: make(map[string]int),
+	}
+	other := new(strings.Builder)
+
+	sc := bufio.NewScanner(strings.NewReader(output))
+	sc.Buffer(make([]byte, 64<<10), maxOutputSize)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+
+		e := &goTestEvent{}
+		if !strings.HasPrefix(line, "{") || json.Unmarshal([]byte(line), e) != nil || e.Action == "" {
+			if strings.TrimSpace(line) != "" {
+				other.WriteString(line + "\n")
+			}
+			continue
+		}
+
+		if e.Test == "" {
+			r.packageEvent(e)
+		} else {
+			r.testEvent(e)
+		}
+	}
+
+	r.Other = other.String()
+
+	return r
+}
+
+func (r *goTestReport) packageEvent(e *goTestEvent) {
+	i, ok := r.packages[e.Package]
+	if !ok {
+		i = len(r.Packages)
+		r.packages[e.Package] = i
+		r.Packages = append(r.Packages, PackageResult{Name: e.Package})
+	}
+	p := &r.Packages[i]
+
+	switch e.Action {
+	case goTestOutput:
+		if m := coverageRe.FindStringSubmatch(e.Output); m != nil {
+			if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+				p.Coverage = &v
+			}
+		}
+		p.Output = appendOutput(p.Output, e.Output)
+	case goTestPass, goTestFail, goTestSkip:
+		p.Status = e.Action
+		p.Elapsed = e.Elapsed
+	}
+}
+
+func (r *goTestReport) testEvent(e *goTestEvent) {
+	key := e.Package + " " + e.Test
+	i, ok := r.tests[key]
+	if !ok {
+		i = len(r.Tests)
+		r.tests[key] = i
+		r.Tests = append(r.Tests, TestResult{Name: e.Test, Package: e.Package})
+	}
+	t := &r.Tests[i]
+
+	switch e.Action {
+	case goTestOutput:
+		t.Output = appendOutput(t.Output, e.Output)
+	case goTestPass, goTestFail, goTestSkip:
+		t.Status = e.Action
+		t.Elapsed = e.Elapsed
+	}
+}
+
+func appendOutput(out string, s string) string {
+	if len(out) >= maxTestOutput {
+		return out
+	}
+	out += s
+	if len(out) > maxTestOutput {
+		out = out[:maxTestOutput]
+	}
+	return out
+}
+
+// evaluateGoTest output, every passed test scores a point, skipped ones do not count
+func evaluateGoTest(out *ContainerOutput) SubmissionResult {
+	rep := parseGoTest(out.Output + out.Stderr)
+
+	if len(rep.Packages) == 0 && len(rep.Tests) == 0 {
+		if out.StatusCode != 0 {
+			return SubmissionResult{Text: out.Output + out.Stderr, Status: StatusFailed, MaxScore: 1}
+		}
+		return internalError("no go test events found")
+	}
+
+	r := SubmissionResult{
+		Pass:     out.StatusCode == 0,
+		Status:   StatusFailed,
+		Tests:    make([]TestResult, 0, len(rep.Tests)),
+		Packages: rep.Packages,
+	}
+
+	passed, skipped := 0, 0
+	for _, t := range rep.Tests {
+		switch t.Status {
+		case goTestSkip:
+			skipped++
+		case goTestPass:
+			passed++
+			t.Pass = true
+			t.Score = 1
+			t.MaxScore = 1
+			t.Output = ""
+		default:
+			// test without a final action did not finish, likely the binary crashed
+			if t.Status == "" {
+				t.Status = goTestFail
+			}
+			r.Pass = false
+			t.MaxScore = 1
+		}
+		r.Score += t.Score
+		r.MaxScore += t.MaxScore
+		r.Tests = append(r.Tests, t)
+	}
+
+	for _, p := range rep.Packages {
+		if p.Status == goTestFail {
+			r.Pass = false
+		}
+	}
+
+	// no tests run at all is not a pass, a successful exit proves nothing
+	if r.MaxScore == 0 {
+		r.Pass = false
+		r.MaxScore = 1
+	}
+
+	if r.Pass {
+		r.Status = StatusPassed
+	}
+	r.Text = goTestSummary(rep, r, passed, skipped)
+
+	return r
+}
+
+func goTestSummary(rep *goTestReport, r SubmissionResult, passed int, skipped int) string {
+	b := new(strings.Builder)
+
+	for _, p := range rep.Packages {
+		fmt.Fprintf(b, "%s %s %.3fs", strings.ToUpper(p.Status), p.Name, p.Elapsed)
+		if p.Coverage != nil {
+			fmt.Fprintf(b, " coverage %.1f%%", *p.Coverage)
+		}
+		b.WriteString("\n")
+	}
+	if rep.Other != "" {
+		fmt.Fprintf(b, "\n%s", truncate(rep.Other))
+	}
+
+	fmt.Fprintf(b, "\nPassed %d of %d tests", passed, len(r.Tests)-skipped)
+	if skipped > 0 {
+		fmt.Fprintf(b, ", skipped %d", skipped)
+	}
+
+	return b.String()
+}
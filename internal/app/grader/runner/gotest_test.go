@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluateGoTest(t *testing.T) {
+	output := strings.Join([]string{
+		`{"Action":"run","Package":"hw1","Test":"TestSum"}`,
+		`{"Action":"output","Package":"hw1","Test":"TestSum","Output":"=== RUN   TestSum\n"}`,
+		`{"Action":"pass","Package":"hw1","Test":"TestSum","Elapsed":0.01}`,
+		`{"Action":"run","Package":"hw1","Test":"TestDiv"}`,
+		`{"Action":"output","Package":"hw1","Test":"TestDiv","Output":"    main_test.go:12: got 1, want 2\n"}`,
+		`{"Action":"fail","Package":"hw1","Test":"TestDiv","Elapsed":0.02}`,
+		`{"Action":"skip","Package":"hw1","Test":"TestSlow","Elapsed":0}`,
+		`{"Action":"output","Package":"hw1","Output":"coverage: 75.5% of statements\n"}`,
+		`{"Action":"fail","Package":"hw1","Elapsed":0.5}`,
+	}, "\r\n")
+
+	r := evaluateGoTest(&ContainerOutput{Output: output, StatusCode: 1})
+
+	if r.Pass || r.Status != StatusFailed {
+		t.Errorf("evaluateGoTest() = %+v, want failed", r)
+	}
+	if r.Score != 1 || r.MaxScore != 2 {
+		t.Errorf("evaluateGoTest() score = %v of %v, want 1 of 2", r.Score, r.MaxScore)
+	}
+	if len(r.Tests) != 3 {
+		t.Fatalf("evaluateGoTest() got %d tests, want 3", len(r.Tests))
+	}
+	if got := r.Tests[1]; got.Status != goTestFail || got.Elapsed != 0.02 || !strings.Contains(got.Output, "want 2") {
+		t.Errorf("evaluateGoTest() failed test = %+v", got)
+	}
+	if len(r.Packages) != 1 || r.Packages[0].Coverage == nil || *r.Packages[0].Coverage != 75.5 {
+		t.Errorf("evaluateGoTest() packages = %+v, want coverage 75.5", r.Packages)
+	}
+	if !strings.HasSuffix(r.Text, "Passed 1 of 2 tests, skipped 1") {
+		t.Errorf("evaluateGoTest() text = %q", r.Text)
+	}
+}
+
+func TestEvaluateGoTest_BuildFailure(t *testing.T) {
+	r := evaluateGoTest(&ContainerOutput{Output: "# hw1\n./main.go:3:1: syntax error\n", StatusCode: 2})
+
+	if r.Pass || r.Status != StatusFailed || !strings.Contains(r.Text, "syntax error") {
+		t.Errorf("evaluateGoTest() = %+v, want failed with build output", r)
+	}
+}
+
+func TestEvaluateGoTest_NoTests(t *testing.T) {
+	output := strings.Join([]string{
+		`{"Action":"output","Package":"hw1","Output":"?   \thw1\t[no test files]\n"}`,
+		`{"Action":"skip","Package":"hw1","Elapsed":0}`,
+	}, "\n")
+
+	r := evaluateGoTest(&ContainerOutput{Output: output, StatusCode: 0})
+
+	if r.Pass || r.Status != StatusFailed {
+		t.Errorf("evaluateGoTest() = %+v, want failed", r)
+	}
+	if r.Score != 0 || r.MaxScore != 1 {
+		t.Errorf("evaluateGoTest() score = %v of %v, want 0 of 1", r.Score, r.MaxScore)
+	}
+	if !strings.HasSuffix(r.Text, "Passed 0 of 0 tests") {
+		t.Errorf("evaluateGoTest() text = %q", r.Text)
+	}
+}
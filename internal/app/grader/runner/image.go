@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"github.com/docker/distribution/reference"
+	"grader/pkg/apperr"
+	"strings"
+)
+
+var (
+	ErrImageNotAllowed = fmt.Errorf("image is not allowed: %w", apperr.ErrInvalidInput)
+	ErrImageNotPinned  = fmt.Errorf("image is not pinned to a digest: %w", apperr.ErrInvalidInput)
+)
+
+// ImagePolicy of the grading images
+type ImagePolicy struct {
+	// Allowed registries and repositories, e.g. docker.io/yarcode or ghcr.io/org/grader-go
+	Allowed []string `mapstructure:"allowed"`
+}
+
+// Check the image is allowed and pinned, only such images are run
+func (p ImagePolicy) Check(image string) error {
+	named, err := p.parse(image)
+	if err != nil {
+		return err
+	}
+	if _, ok := named.(reference.Canonical); !ok {
+		return fmt.Errorf("%s: %w", image, ErrImageNotPinned)
+	}
+
+	return nil
+}
+
+// parse the image reference and check it against the allowlist
+func (p ImagePolicy) parse(image string) (reference.Named, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v: %w", image, err, apperr.ErrInvalidInput)
+	}
+
+	name := named.Name()
+	for _, a := range p.Allowed {
+		a = strings.TrimRight(strings.TrimSpace(a), "/")
+		if a != "" && (name == a || strings.HasPrefix(name, a+"/")) {
+			return named, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s: %w", image, ErrImageNotAllowed)
+}
+
+// ResolveImage to the reference pinned to its digest by the executor
+func ResolveImage(ctx context.Context, exec Executor, policy ImagePolicy, image string) (string, error) {
+	named, err := policy.parse(image)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := named.(reference.Canonical); ok {
+		return named.String(), nil
+	}
+
+	return exec.ResolveImage(ctx, reference.TagNameOnly(named).String())
+}
@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"grader/pkg/apperr"
+	"testing"
+)
+
+func TestImagePolicy_Check(t *testing.T) {
+	const digest = "@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	policy := ImagePolicy{Allowed: []string{"docker.io/yarcode", "ghcr.io/org/grader-go/"}}
+
+	tests := []struct {
+		name  string
+		image string
+		want  error
+	}{
+		{name: "pinned", image: "yarcode/grader" + digest},
+		{name: "pinned with tag", image: "yarcode/grader:latest" + digest},
+		{name: "repository", image: "ghcr.io/org/grader-go" + digest},
+		{name: "tag", image: "yarcode/grader:latest", want: ErrImageNotPinned},
+		{name: "other namespace", image: "yarcodex/grader" + digest, want: ErrImageNotAllowed},
+		{name: "other registry", image: "ghcr.io/yarcode/grader" + digest, want: ErrImageNotAllowed},
+		{name: "official", image: "golang" + digest, want: ErrImageNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.image)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Check() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveImage(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	policy := ImagePolicy{Allowed: []string{"docker.io/yarcode"}}
+	exec := &FakeExecutor{Digests: map[string]string{"docker.io/yarcode/grader:latest": digest}}
+
+	tests := []struct {
+		name    string
+		image   string
+		want    string
+		wantErr error
+	}{
+		{name: "tag", image: "yarcode/grader:latest", want: "docker.io/yarcode/grader:latest@" + digest},
+		{name: "default tag", image: "yarcode/grader", want: "docker.io/yarcode/grader:latest@" + digest},
+		{name: "pinned already", image: "yarcode/other@" + digest, want: "docker.io/yarcode/other@" + digest},
+		{name: "no digest", image: "yarcode/local:dev", wantErr: apperr.ErrInvalidInput},
+		{name: "not allowed", image: "golang:1.17", wantErr: ErrImageNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveImage(context.Background(), exec, policy, tt.image)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ResolveImage() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ResolveImage() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
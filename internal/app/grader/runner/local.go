@@ -0,0 +1,222 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/docker/distribution/reference"
+	"github.com/google/uuid"
+	"grader/pkg/apperr"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type LocalConfig struct {
+	// Command running the grading, the run arguments are appended to it
+	Command []string `mapstructure:"command"`
+	// Prlimit binary applying the rlimits, limits are not applied if empty
+	Prlimit string `mapstructure:"prlimit"`
+}
+
+// LocalExecutor runs the grading command on the host, the image is not used.
+// Sandbox limits are applied as rlimits, neither network nor filesystem are isolated,
+// so it is meant for trusted submissions and hosts without Docker.
+// PidsLimit is applied as RLIMIT_NPROC which counts all the processes of the user rather than the task,
+// so concurrent runs as the same user share it. CPUs is not applied as there is no rlimit for the CPU share.
+type LocalExecutor struct {
+	command []string
+	prlimit string
+}
+
+func NewLocalExecutor(cfg LocalConfig) (*LocalExecutor, error) {
+	if len(cfg.Command) == 0 {
+		return nil, errors.New("local executor: command is required")
+	}
+
+	return &LocalExecutor{command: cfg.Command, prlimit: cfg.Prlimit}, nil
+}
+
+// Prepare implementation of Executor
+func (e *LocalExecutor) Prepare(_ context.Context, submission Submission) (*Workspace, error) {
+	return newWorkspace(submission)
+}
+
+// Run implementation of Executor, the command is run in the submission dir
+func (e *LocalExecutor) Run(ctx context.Context, ws *Workspace, spec RunSpec) (*ContainerOutput, error) {
+	args := append(e.limits(spec.Sandbox), e.command...)
+	args = append(args, spec.Cmd...)
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = ws.SubmissionDir
+	// grader environment is not passed as it may contain secrets
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + ws.SubmissionDir,
+		"SUBMISSION_DIR=" + ws.SubmissionDir,
+	}
+	if spec.Result {
+		cmd.Env = append(cmd.Env, "RESULT_DIR="+ws.ResultDir)
+	}
+	if err := configureProcess(cmd, spec.Sandbox); err != nil {
+		return nil, err
+	}
+
+	stdout := &limitedBuffer{limit: maxOutputSize}
+	stderr := stdout
+	if spec.Stdin != nil {
+		cmd.Stdin = strings.NewReader(*spec.Stdin)
+		stderr = &limitedBuffer{limit: maxOutputSize}
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("command start: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var timeout <-chan time.Time
+	if spec.Sandbox.Timeout > 0 {
+		t := time.NewTimer(time.Duration(spec.Sandbox.Timeout) * time.Second)
+		defer t.Stop()
+		timeout = t.C
+	}
+
+	var err error
+	select {
+	case err = <-done:
+	case <-timeout:
+		_ = killProcess(cmd)
+		<-done
+		out := stdout.String()
+		if stderr != stdout {
+			out += stderr.String()
+		}
+		return nil, TimeoutError{Output: out, Timeout: time.Duration(spec.Sandbox.Timeout) * time.Second}
+	case <-ctx.Done():
+		_ = killProcess(cmd)
+		<-done
+		return nil, fmt.Errorf("command: %w", ctx.Err())
+	}
+
+	out := &ContainerOutput{Output: stdout.String()}
+	if stderr != stdout {
+		out.Stderr = stderr.String()
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		out.StatusCode = int64(exitErr.ExitCode())
+	case err != nil:
+		return nil, fmt.Errorf("command: %w", err)
+	}
+
+	return out, nil
+}
+
+// Cleanup implementation of Executor
+func (e *LocalExecutor) Cleanup(ws *Workspace) {
+	removeWorkspace(ws)
+}
+
+// ResolveImage implementation of Executor, the image is never run, so it is pinned
+// to the digest of the local command and changes along with it
+func (e *LocalExecutor) ResolveImage(_ context.Context, image string) (string, error) {
+	sum := sha256.Sum256([]byte(strings.Join(e.command, "\x00")))
+
+	pinned, err := reference.ParseNormalizedNamed(image + "@sha256:" + hex.EncodeToString(sum[:]))
+	if err != nil {
+		return "", fmt.Errorf("%s: %v: %w", image, err, apperr.ErrInvalidInput)
+	}
+
+	return pinned.String(), nil
+}
+
+// PrewarmImage implementation of Executor, there is nothing to pull
+func (e *LocalExecutor) PrewarmImage(_ context.Context, _ string) error {
+	return nil
+}
+
+// FollowLogs implementation of Executor, the output is available with the result only
+func (e *LocalExecutor) FollowLogs(_ context.Context, _ uuid.UUID, _ io.Writer, _ func() bool) error {
+	return fmt.Errorf("local executor logs: %w", ErrNotSupported)
+}
+
+// Containers implementation of Executor, the commands are children of the grader and go away with it
+func (e *LocalExecutor) Containers(_ context.Context) ([]Container, error) {
+	return nil, nil
+}
+
+// RemoveContainer implementation of Executor, there are no containers to remove
+func (e *LocalExecutor) RemoveContainer(_ context.Context, _ string) error {
+	return fmt.Errorf("local executor containers: %w", ErrNotSupported)
+}
+
+// limits of the sandbox as the prlimit command prefix
+func (e *LocalExecutor) limits(s Sandbox) []string {
+	if e.prlimit == "" {
+		return nil
+	}
+
+	var args []string
+	if s.MemoryMB > 0 {
+		args = append(args, "--as="+strconv.FormatInt(s.MemoryMB*1024*1024, 10))
+	}
+	// per user rather than per task
+	if s.PidsLimit > 0 {
+		args = append(args, "--nproc="+strconv.FormatInt(s.PidsLimit, 10))
+	}
+	if s.TmpfsMB > 0 {
+		args = append(args, "--fsize="+strconv.FormatInt(s.TmpfsMB*1024*1024, 10))
+	}
+	if s.Timeout > 0 {
+		args = append(args, "--cpu="+strconv.FormatInt(s.Timeout, 10))
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	return append(append([]string{e.prlimit}, args...), "--")
+}
+
+// limitedBuffer keeps the first limit bytes written, the rest is discarded
+type limitedBuffer struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if left := b.limit - b.buf.Len(); left > 0 {
+		if len(p) > left {
+			b.buf.Write(p[:left])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
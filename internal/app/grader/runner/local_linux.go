@@ -0,0 +1,56 @@
+//go:build linux
+
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// configureProcess to run in its own process group as the sandbox user, the user is switched when run by root only
+func configureProcess(cmd *exec.Cmd, s Sandbox) error {
+	attr := &syscall.SysProcAttr{
+		Setpgid:   true,
+		Pdeathsig: syscall.SIGKILL,
+	}
+
+	if s.User != "" && syscall.Geteuid() == 0 {
+		uid, gid, err := parseUser(s.User)
+		if err != nil {
+			return err
+		}
+		attr.Credential = &syscall.Credential{Uid: uid, Gid: gid}
+	}
+
+	cmd.SysProcAttr = attr
+
+	return nil
+}
+
+// killProcess with all its children
+func killProcess(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// parseUser of the uid:gid form, names are not resolved
+func parseUser(v string) (uint32, uint32, error) {
+	parts := strings.SplitN(v, ":", 2)
+
+	uid, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("sandbox user %q: %w", v, err)
+	}
+
+	gid := uid
+	if len(parts) == 2 {
+		gid, err = strconv.ParseUint(parts[1], 10, 32)
+		if err != nil {
+			return 0, 0, fmt.Errorf("sandbox user %q: %w", v, err)
+		}
+	}
+
+	return uint32(uid), uint32(gid), nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package runner
+
+import (
+	"os/exec"
+)
+
+// configureProcess is a no-op, the sandbox user is not switched
+func configureProcess(_ *exec.Cmd, _ Sandbox) error {
+	return nil
+}
+
+// killProcess without its children, they are not grouped
+func killProcess(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,113 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestLocalExecutor_Run(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	e, err := NewLocalExecutor(LocalConfig{Command: []string{"sh", "-c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws, err := e.Prepare(context.Background(), Submission{TaskID: uuid.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Cleanup(ws)
+
+	input := "ping"
+
+	tests := []struct {
+		name    string
+		spec    RunSpec
+		want    ContainerOutput
+		timeout bool
+	}{
+		{
+			name: "merged output",
+			spec: RunSpec{Cmd: []string{`echo out; echo err >&2; test -d "$RESULT_DIR" && exit 3`}, Result: true},
+			want: ContainerOutput{Output: "out\nerr\n", StatusCode: 3},
+		},
+		{
+			name: "stdin",
+			spec: RunSpec{Cmd: []string{`read v; echo "$v"; echo err >&2`}, Stdin: &input},
+			want: ContainerOutput{Output: "ping\n", Stderr: "err\n"},
+		},
+		{
+			name:    "timeout",
+			spec:    RunSpec{Cmd: []string{`echo started; sleep 10 & wait`}, Sandbox: Sandbox{Timeout: 1}},
+			timeout: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := e.Run(context.Background(), ws, tt.spec)
+
+			var timeoutErr TimeoutError
+			if tt.timeout {
+				if !errors.As(err, &timeoutErr) || timeoutErr.Output != "started\n" {
+					t.Errorf("Run() error = %v, want timeout with partial output", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if *out != tt.want {
+				t.Errorf("Run() = %+v, want %+v", *out, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalExecutor_limits(t *testing.T) {
+	e := &LocalExecutor{prlimit: "prlimit"}
+
+	got := e.limits(Sandbox{MemoryMB: 1, PidsLimit: 8, Timeout: 5, CPUs: 1})
+	want := []string{"prlimit", "--as=1048576", "--nproc=8", "--cpu=5", "--"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("limits() = %v, want %v", got, want)
+	}
+
+	if got := e.limits(Sandbox{CPUs: 1}); got != nil {
+		t.Errorf("limits() = %v, want none", got)
+	}
+}
+
+func TestLocalExecutor_images(t *testing.T) {
+	policy := ImagePolicy{Allowed: []string{"docker.io/yarcode"}}
+	e := &LocalExecutor{command: []string{"sh", "-c"}}
+
+	// the image is not run, still the assessments are pinned to be accepted
+	pinned, err := ResolveImage(context.Background(), e, policy, "yarcode/grader:latest")
+	if err != nil {
+		t.Fatalf("ResolveImage() error = %v", err)
+	}
+	if err := policy.Check(pinned); err != nil {
+		t.Errorf("Check(%s) error = %v", pinned, err)
+	}
+
+	other := &LocalExecutor{command: []string{"bash", "-c"}}
+	if got, _ := ResolveImage(context.Background(), other, policy, "yarcode/grader:latest"); got == pinned {
+		t.Errorf("ResolveImage() = %s for another command, want another digest", got)
+	}
+
+	if got := PrewarmImages(context.Background(), e, policy, []string{pinned}); got[0].Error != "" {
+		t.Errorf("PrewarmImages() = %+v, want no error", got)
+	}
+
+	if err := e.FollowLogs(context.Background(), uuid.New(), nil, nil); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("FollowLogs() error = %v, want %v", err, ErrNotSupported)
+	}
+}
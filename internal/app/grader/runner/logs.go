@@ -0,0 +1,101 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/google/uuid"
+	"io"
+	"sort"
+	"time"
+)
+
+// logsPollInterval while waiting for the next container of the task
+const logsPollInterval = 500 * time.Millisecond
+
+// FollowLogs implementation of Executor, output of the containers removed before they were found is not available
+func (e *DockerExecutor) FollowLogs(ctx context.Context, taskID uuid.UUID, w io.Writer, finished func() bool) error {
+	cli := e.cli
+	followed := make(map[string]bool)
+
+	for {
+		id, err := nextContainer(ctx, cli, taskID, followed)
+		if err != nil {
+			return err
+		}
+
+		if id == "" {
+			if finished() {
+				return nil
+			}
+			select {
+			case <-time.After(logsPollInterval):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		followed[id] = true
+		if err := followContainer(ctx, cli, id, w); err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+	}
+}
+
+// nextContainer of the task which is not followed yet in order of creation, empty if there is none
+func nextContainer(ctx context.Context, cli *client.Client, taskID uuid.UUID, followed map[string]bool) (string, error) {
+	list, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("name", containerName(taskID))),
+	})
+	if err != nil {
+		return "", fmt.Errorf("container list: %w", err)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created < list[j].Created
+	})
+
+	for _, c := range list {
+		if !followed[c.ID] {
+			return c.ID, nil
+		}
+	}
+
+	return "", nil
+}
+
+// followContainer logs until it stops
+func followContainer(ctx context.Context, cli *client.Client, containerID string, w io.Writer) error {
+	info, err := cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("container inspect: %w", err)
+	}
+
+	out, err := cli.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+	})
+	if err != nil {
+		return fmt.Errorf("container logs: %w", err)
+	}
+	defer func(logs io.ReadCloser) {
+		_ = logs.Close()
+	}(out)
+
+	if info.Config != nil && info.Config.Tty {
+		_, err = io.Copy(w, out)
+	} else {
+		_, err = stdcopy.StdCopy(w, w, out)
+	}
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("container logs read: %w", err)
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,204 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"grader/pkg/logger"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ResultModeExitCode treats zero exit code as pass and shows the output
+	ResultModeExitCode = "exit_code"
+	// ResultModeJSON expects the container to report a Verdict
+	ResultModeJSON = "json"
+	// ResultModeIO runs the container for every TestCase and compares its output
+	ResultModeIO = "io"
+	// ResultModeGoTest parses the `go test -json` event stream of the container
+	ResultModeGoTest = "go_test"
+)
+
+const (
+	// resultMountDir where the container may write resultFileName if it does not print the verdict
+	resultMountDir = "/app/result"
+	resultFileName = "result.json"
+	// maxResultFileSize to be read from the result file
+	maxResultFileSize = 1 << 20
+)
+
+var errNoVerdict = errors.New("no verdict found")
+
+// Verdict reported by the container in ResultModeJSON
+type Verdict struct {
+	Pass     *bool        `json:"pass"`
+	Text     string       `json:"text"`
+	Score    *float64     `json:"score"`
+	MaxScore float64      `json:"max_score"`
+	Tests    []TestResult `json:"tests"`
+}
+
+// normalize missing verdict fields, at least pass or score must be reported
+func (v *Verdict) normalize() error {
+	if v.Pass == nil && v.Score == nil {
+		return errNoVerdict
+	}
+
+	if v.MaxScore <= 0 {
+		for _, t := range v.Tests {
+			v.MaxScore += t.MaxScore
+		}
+	}
+	if v.MaxScore <= 0 {
+		v.MaxScore = 1
+	}
+
+	if v.Score == nil {
+		score := 0.0
+		if *v.Pass {
+			score = v.MaxScore
+		}
+		v.Score = &score
+	}
+	if v.Pass == nil {
+		pass := *v.Score >= v.MaxScore
+		v.Pass = &pass
+	}
+
+	return nil
+}
+
+// evaluate container output according to the result mode
+func evaluate(l logger.Logger, mode string, out *ContainerOutput, resultDir string) SubmissionResult {
+	if mode == ResultModeGoTest {
+		return evaluateGoTest(out)
+	}
+
+	if mode != ResultModeJSON {
+		if out.StatusCode != 0 {
+			return SubmissionResult{Text: out.Output, Status: StatusFailed, MaxScore: 1}
+		}
+		return SubmissionResult{Pass: true, Text: "OK", Status: StatusPassed, Score: 1, MaxScore: 1}
+	}
+
+	// grading container itself must not fail, failed submission is reported in the verdict
+	if out.StatusCode != 0 {
+		l.Debug().Int64("container_status", out.StatusCode).Str("output", out.Output).Msg("Grading container failed")
+		return internalError(fmt.Sprintf("grader exited with code %d", out.StatusCode))
+	}
+
+	v, err := readVerdict(out.Output, filepath.Join(resultDir, resultFileName))
+	if err != nil {
+		l.Debug().Err(err).Str("output", out.Output).Msg("Unable to read verdict")
+		return internalError(err.Error())
+	}
+
+	r := SubmissionResult{
+		Pass:     *v.Pass,
+		Text:     v.Text,
+		Status:   StatusFailed,
+		Score:    *v.Score,
+		MaxScore: v.MaxScore,
+		Tests:    v.Tests,
+	}
+	if r.Pass {
+		r.Status = StatusPassed
+	}
+
+	return r
+}
+
+func internalError(reason string) SubmissionResult {
+	return SubmissionResult{
+		Text:   "Internal error: " + reason,
+		Status: StatusError,
+	}
+}
+
+// readVerdict from the last line of the output, the result file is read only if there is no verdict there:
+// the code under test shares the container and may write the file, but the harness prints last
+func readVerdict(output string, resultFile string) (*Verdict, error) {
+	v, err := decodeVerdict([]byte(lastLine(output)))
+	if err == nil {
+		return v, nil
+	}
+
+	b, fileErr := readResultFile(resultFile)
+	if fileErr != nil {
+		return nil, fileErr
+	}
+	if b == nil {
+		return nil, err
+	}
+
+	return decodeVerdict(b)
+}
+
+func decodeVerdict(b []byte) (*Verdict, error) {
+	if len(b) == 0 {
+		return nil, errNoVerdict
+	}
+
+	v := &Verdict{}
+	if err := json.Unmarshal(b, v); err != nil {
+		return nil, fmt.Errorf("verdict decode: %w", err)
+	}
+	if err := v.normalize(); err != nil {
+		return nil, fmt.Errorf("verdict decode: %w", err)
+	}
+
+	return v, nil
+}
+
+// readResultFile contents, nil if there is no file. The dir is writable by the container,
+// so links are not followed, they could point to any file of the host
+func readResultFile(path string) ([]byte, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("result file: %w", err)
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("result file is not a regular file")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("result file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	// the file may be replaced between the checks
+	opened, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("result file: %w", err)
+	}
+	if !os.SameFile(fi, opened) {
+		return nil, fmt.Errorf("result file is replaced while reading")
+	}
+
+	b, err := io.ReadAll(io.LimitReader(f, maxResultFileSize))
+	if err != nil {
+		return nil, fmt.Errorf("result file read: %w", err)
+	}
+
+	return b, nil
+}
+
+// lastLine of the output which is not empty, TTY line endings included
+func lastLine(output string) string {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return line
+		}
+	}
+	return ""
+}
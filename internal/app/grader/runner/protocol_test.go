@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"grader/pkg/logger"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	l := logger.Global().WithComponent("TestEvaluate")
+
+	resultDir := t.TempDir()
+	fileDir := t.TempDir()
+	if err := ioutil.WriteFile(
+		filepath.Join(fileDir, resultFileName),
+		[]byte(`{"pass": false, "text": "from file"}`),
+		0644,
+	); err != nil {
+		t.Fatalf("an error '%s' was not expected when writing result file", err)
+	}
+
+	// the container may point the result file anywhere on the host
+	linkDir := t.TempDir()
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := ioutil.WriteFile(secret, []byte(`{"pass": true, "text": "host secret"}`), 0600); err != nil {
+		t.Fatalf("an error '%s' was not expected when writing secret file", err)
+	}
+	if err := os.Symlink(secret, filepath.Join(linkDir, resultFileName)); err != nil {
+		t.Fatalf("an error '%s' was not expected when linking result file", err)
+	}
+
+	tests := []struct {
+		name      string
+		mode      string
+		out       *ContainerOutput
+		resultDir string
+		want      SubmissionResult
+	}{
+		{
+			name: "exit code pass",
+			mode: ResultModeExitCode,
+			out:  &ContainerOutput{Output: "ok", StatusCode: 0},
+			want: SubmissionResult{Pass: true, Text: "OK", Status: StatusPassed, Score: 1, MaxScore: 1},
+		},
+		{
+			name: "exit code fail",
+			mode: "",
+			out:  &ContainerOutput{Output: "FAIL", StatusCode: 1},
+			want: SubmissionResult{Pass: false, Text: "FAIL", Status: StatusFailed, MaxScore: 1},
+		},
+		{
+			name:      "json verdict on the last line",
+			mode:      ResultModeJSON,
+			out:       &ContainerOutput{Output: "compiling\r\n{\"pass\": true, \"text\": \"Well done\"}\r\n\r\n"},
+			resultDir: resultDir,
+			want:      SubmissionResult{Pass: true, Text: "Well done", Status: StatusPassed, Score: 1, MaxScore: 1},
+		},
+		{
+			name:      "json verdict in the result file",
+			mode:      ResultModeJSON,
+			out:       &ContainerOutput{Output: "compiling"},
+			resultDir: fileDir,
+			want:      SubmissionResult{Pass: false, Text: "from file", Status: StatusFailed, MaxScore: 1},
+		},
+		{
+			name:      "json verdict of the output takes precedence over the result file",
+			mode:      ResultModeJSON,
+			out:       &ContainerOutput{Output: "{\"pass\": true, \"text\": \"from output\"}"},
+			resultDir: fileDir,
+			want:      SubmissionResult{Pass: true, Text: "from output", Status: StatusPassed, Score: 1, MaxScore: 1},
+		},
+		{
+			name:      "json verdict result file linked outside",
+			mode:      ResultModeJSON,
+			out:       &ContainerOutput{Output: "compiling"},
+			resultDir: linkDir,
+			want:      SubmissionResult{Pass: false, Text: "Internal error: result file is not a regular file", Status: StatusError},
+		},
+		{
+			name:      "json verdict missing",
+			mode:      ResultModeJSON,
+			out:       &ContainerOutput{Output: "panic: oops"},
+			resultDir: resultDir,
+			want:      SubmissionResult{Pass: false, Text: "Internal error: verdict decode: invalid character 'p' looking for beginning of value", Status: StatusError},
+		},
+		{
+			name: "json verdict with partial score",
+			mode: ResultModeJSON,
+			out: &ContainerOutput{
+				Output: `{"score": 1, "tests": [{"name": "a", "pass": true, "score": 1, "max_score": 1}, {"name": "b", "max_score": 2}]}`,
+			},
+			resultDir: resultDir,
+			want: SubmissionResult{
+				Status:   StatusFailed,
+				Score:    1,
+				MaxScore: 3,
+				Tests: []TestResult{
+					{Name: "a", Pass: true, Score: 1, MaxScore: 1},
+					{Name: "b", MaxScore: 2},
+				},
+			},
+		},
+		{
+			name:      "json verdict without pass",
+			mode:      ResultModeJSON,
+			out:       &ContainerOutput{Output: "{\"text\": \"?\"}"},
+			resultDir: resultDir,
+			want:      SubmissionResult{Pass: false, Text: "Internal error: verdict decode: no verdict found", Status: StatusError},
+		},
+		{
+			name:      "json mode container failure",
+			mode:      ResultModeJSON,
+			out:       &ContainerOutput{Output: "{\"pass\": true, \"text\": \"\"}", StatusCode: 2},
+			resultDir: resultDir,
+			want:      SubmissionResult{Pass: false, Text: "Internal error: grader exited with code 2", Status: StatusError},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluate(l, tt.mode, tt.out, tt.resultDir)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("evaluate() got = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
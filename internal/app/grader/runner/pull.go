@@ -0,0 +1,152 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"golang.org/x/sync/singleflight"
+	"grader/internal/pkg/graderapi"
+	"grader/pkg/logger"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// PullAlways pulls the image before every run
+	PullAlways = "always"
+	// PullIfNotPresent pulls the image missing on the host only
+	PullIfNotPresent = "if_not_present"
+	// PullNever runs the images present on the host only, they are expected to be pre-warmed
+	PullNever = "never"
+)
+
+// pullTimeout of a single image pull shared by the waiting submissions
+const pullTimeout = 10 * time.Minute
+
+var ErrImageNotPresent = errors.New("image is not present on the host")
+
+// pulls in progress by the image reference
+var pulls singleflight.Group
+
+// PrewarmResult of a single image, the wire format is shared with the panel
+type PrewarmResult = graderapi.PrewarmResult
+
+// PrewarmImages allowed by the policy by the executor, so submissions do not wait for them
+func PrewarmImages(ctx context.Context, exec Executor, policy ImagePolicy, images []string) []PrewarmResult {
+	out := make([]PrewarmResult, len(images))
+
+	var wg sync.WaitGroup
+	for i, image := range images {
+		out[i].Image = image
+
+		if err := policy.Check(image); err != nil {
+			out[i].Error = err.Error()
+			continue
+		}
+
+		wg.Add(1)
+		go func(r *PrewarmResult) {
+			defer wg.Done()
+			if err := exec.PrewarmImage(ctx, r.Image); err != nil {
+				r.Error = err.Error()
+			}
+		}(&out[i])
+	}
+	wg.Wait()
+
+	return out
+}
+
+// ensureImage is present on the host according to the pull policy
+func ensureImage(ctx context.Context, l logger.Logger, cli *client.Client, policy string, image string) error {
+	switch policy {
+	case PullAlways:
+		return pullImage(ctx, l, cli, image)
+	case PullIfNotPresent, PullNever:
+	default:
+		return fmt.Errorf("unknown pull policy %q", policy)
+	}
+
+	_, _, err := cli.ImageInspectWithRaw(ctx, image)
+	switch {
+	case err == nil:
+		return nil
+	case !client.IsErrNotFound(err):
+		return fmt.Errorf("image inspect: %w", err)
+	case policy == PullNever:
+		return fmt.Errorf("%s: %w", image, ErrImageNotPresent)
+	}
+
+	return pullImage(ctx, l, cli, image)
+}
+
+// pullImage once for all the concurrent callers, the pull goes on if some of them give up waiting
+func pullImage(ctx context.Context, l logger.Logger, cli *client.Client, image string) error {
+	ch := pulls.DoChan(image, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), pullTimeout)
+		defer cancel()
+
+		return nil, doPull(ctx, l, cli, image)
+	})
+
+	select {
+	case r := <-ch:
+		return r.Err
+	case <-ctx.Done():
+		return fmt.Errorf("image pull: %w", ctx.Err())
+	}
+}
+
+func doPull(ctx context.Context, l logger.Logger, cli *client.Client, image string) error {
+	l.Debug().Str("container_image", image).Msg("Pulling image")
+	started := time.Now()
+
+	out, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return fmt.Errorf("image pull: %w", err)
+	}
+	defer func(out io.ReadCloser) {
+		_ = out.Close()
+	}(out)
+
+	if err := readPullProgress(out); err != nil {
+		return fmt.Errorf("image pull %s: %w", image, err)
+	}
+
+	l.Info().Str("container_image", image).Dur("elapsed", time.Since(started)).Msg("Image pulled")
+
+	return nil
+}
+
+// pullMessage of the pull progress stream
+type pullMessage struct {
+	Error       string `json:"error"`
+	ErrorDetail *struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// readPullProgress till the end of the stream, the pull is done by then, failures are reported in the stream
+func readPullProgress(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	for {
+		var m pullMessage
+		if err := dec.Decode(&m); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("progress: %w", err)
+		}
+
+		switch {
+		case m.ErrorDetail != nil && m.ErrorDetail.Message != "":
+			return errors.New(m.ErrorDetail.Message)
+		case m.Error != "":
+			return errors.New(m.Error)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadPullProgress(t *testing.T) {
+	tests := []struct {
+		name    string
+		stream  string
+		wantErr string
+	}{
+		{
+			name: "pulled",
+			stream: `{"status":"Pulling from yarcode/grader","id":"latest"}
+{"status":"Downloading","progressDetail":{"current":1,"total":2},"id":"a1"}
+{"status":"Status: Downloaded newer image for yarcode/grader:latest"}
+`,
+		},
+		{
+			name: "error detail",
+			stream: `{"status":"Pulling from yarcode/grader","id":"latest"}
+{"errorDetail":{"message":"manifest unknown"},"error":"manifest unknown: manifest unknown"}
+`,
+			wantErr: "manifest unknown",
+		},
+		{
+			name:    "truncated",
+			stream:  `{"status":"Downloading"`,
+			wantErr: "progress: unexpected EOF",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := readPullProgress(strings.NewReader(tt.stream))
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("readPullProgress() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrewarmImages(t *testing.T) {
+	const pinned = "yarcode/grader@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	policy := ImagePolicy{Allowed: []string{"docker.io/yarcode"}}
+	exec := &FakeExecutor{}
+
+	got := PrewarmImages(context.Background(), exec, policy, []string{pinned, "yarcode/grader:latest"})
+
+	if len(got) != 2 || got[0].Error != "" || !strings.Contains(got[1].Error, ErrImageNotPinned.Error()) {
+		t.Errorf("PrewarmImages() = %+v, want the pinned image only", got)
+	}
+	// images refused by the policy never reach the executor
+	if want := []string{pinned}; !reflect.DeepEqual(exec.Prewarmed(), want) {
+		t.Errorf("Prewarmed() = %v, want %v", exec.Prewarmed(), want)
+	}
+}
@@ -5,26 +5,79 @@ import (
 	"fmt"
 	"github.com/go-resty/resty/v2"
 	"github.com/google/uuid"
+	"grader/internal/pkg/graderapi"
+	"net/http"
 )
 
-type SubmissionResult struct {
-	TaskID uuid.UUID `json:"task_id"`
-	Pass   bool      `json:"pass"`
-	Text   string    `json:"text"`
+const (
+	StatusPassed      = "passed"
+	StatusFailed      = "failed"
+	StatusTimeout     = "timeout"
+	StatusError       = "error"
+	StatusCancelled   = "cancelled"
+	StatusInterrupted = "interrupted"
+)
+
+// SubmissionResult posted back to the submitter, the wire format is shared with the panel
+type SubmissionResult = graderapi.SubmissionResult
+
+// TestResult of a single test within the submission
+type TestResult = graderapi.TestResult
+
+// PackageResult of the go test run
+type PackageResult = graderapi.PackageResult
+
+// CancelledResult reported for the task cancelled on request
+func CancelledResult(taskID uuid.UUID) SubmissionResult {
+	return SubmissionResult{
+		TaskID: taskID,
+		Text:   "Cancelled",
+		Status: StatusCancelled,
+	}
+}
+
+// InterruptedResult reported for the task lost by the grader restart
+func InterruptedResult(taskID uuid.UUID) SubmissionResult {
+	return SubmissionResult{
+		TaskID: taskID,
+		Text:   "Interrupted: grader restarted while checking the submission, please submit again",
+		Status: StatusInterrupted,
+	}
+}
+
+// PostbackError of the postback rejected by the receiver
+type PostbackError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *PostbackError) Error() string {
+	return fmt.Sprintf("result postback: %s", e.Status)
 }
 
-// sendResult to callback URL
-func sendResult(ctx context.Context, URL string, result SubmissionResult) error {
-	client := resty.New()
+// Permanent reports if retrying the postback is pointless
+func (e *PostbackError) Permanent() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	}
+	return e.StatusCode >= http.StatusBadRequest && e.StatusCode < http.StatusInternalServerError
+}
 
-	_, err := client.R().
+// SendResult to the postback URL of the submission, non-2xx responses are reported as PostbackError
+func SendResult(ctx context.Context, client *resty.Client, submission Submission, result SubmissionResult) error {
+	resp, err := client.R().
 		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
+		SetAuthToken(submission.PostbackToken).
 		SetBody(result).
-		Post(URL)
+		Post(submission.PostbackURL)
 	if err != nil {
 		return fmt.Errorf("result postback: %w", err)
 	}
+	if !resp.IsSuccess() {
+		return &PostbackError{StatusCode: resp.StatusCode(), Status: resp.Status()}
+	}
 
 	return nil
 }
@@ -2,174 +2,231 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
 	"github.com/google/uuid"
 	"golang.org/x/sync/errgroup"
+	"grader/pkg/apperr"
 	"grader/pkg/logger"
 	"grader/pkg/workerpool"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 	"time"
 )
 
 type Submission struct {
 	TaskID         uuid.UUID        `json:"-"`
-	ContainerImage string           `json:"container_image" validate:"required"`
-	PartID         string           `json:"part_id" validate:"required"`
-	PostbackURL    string           `json:"postback_url" validate:"required,url"`
-	Files          []SubmissionFile `json:"files" validate:"required"`
+	ContainerImage string           `json:"container_image"`
+	PartID         string           `json:"part_id"`
+	PostbackURL    string           `json:"postback_url"`
+	PostbackToken  string           `json:"postback_token"`
+	Files          []SubmissionFile `json:"files"`
+	Sandbox        *Sandbox         `json:"sandbox,omitempty"`
+	ResultMode     string           `json:"result_mode,omitempty"`
+	TestCases      []TestCase       `json:"test_cases,omitempty"`
 }
 
 type SubmissionFile struct {
-	Name string `json:"name" validate:"required"`
-	URL  string `json:"url" validate:"required,url"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
 }
 
-type ContainerError struct {
+// CheckFiles have unique clean relative paths, e.g. of the unpacked archive,
+// they are written to the submission dir as is
+func (s Submission) CheckFiles() error {
+	names := make(map[string]bool, len(s.Files))
+	for _, f := range s.Files {
+		if !validFilePath(f.Name) {
+			return fmt.Errorf("%w: invalid file name %q", apperr.ErrInvalidInput, f.Name)
+		}
+		if names[f.Name] {
+			return fmt.Errorf("%w: duplicate file name %q", apperr.ErrInvalidInput, f.Name)
+		}
+		names[f.Name] = true
+	}
+
+	// a file can not be the dir of another one
+	for name := range names {
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			if names[dir] {
+				return fmt.Errorf("%w: file %q conflicts with dir of %q", apperr.ErrInvalidInput, dir, name)
+			}
+		}
+	}
+	return nil
+}
+
+func validFilePath(name string) bool {
+	return name != "" && name != "." &&
+		path.Clean(name) == name &&
+		!path.IsAbs(name) &&
+		name != ".." && !strings.HasPrefix(name, "../") &&
+		!strings.Contains(name, "\\")
+}
+
+// cleanupTimeout for container operations which run after the job context is done
+const cleanupTimeout = 30 * time.Second
+
+// maxOutputSize of the container logs kept for a result
+const maxOutputSize = 4 << 20
+
+// ContainerOutput of the finished container, Stderr is empty for TTY runs as it is merged into Output
+type ContainerOutput struct {
 	Output     string
+	Stderr     string
 	StatusCode int64
 }
 
-func (c ContainerError) Error() string {
-	return c.Output
+// TimeoutError of the container killed after running out of time
+type TimeoutError struct {
+	Output  string
+	Timeout time.Duration
 }
 
-func CheckSubmissionJob(submission Submission) workerpool.Job {
-	return func(ctx context.Context) error {
-		l := logger.Global().WithComponent("CheckSubmissionJob")
-
-		l.Debug().Msg("Creating temporary dir")
-		tempDir, err := ioutil.TempDir("", "submission*")
-		if err != nil {
-			return fmt.Errorf("temp dir: %w", err)
-		}
-		defer func(path string) {
-			_ = os.RemoveAll(path)
-		}(tempDir)
-		l.Debug().Str("path", tempDir).Msg("Using temporary dir")
+func (t TimeoutError) Error() string {
+	return fmt.Sprintf("Timeout: execution exceeded %s and was stopped\n\n%s", t.Timeout, t.Output)
+}
 
-		if err := fetchSubmissionFiles(ctx, tempDir, submission.Files); err != nil {
-			return fmt.Errorf("fetch files: %w", err)
-		}
+// Tracker of the task state changes
+type Tracker interface {
+	// Running task gets the context which is cancelled on request, false if it was cancelled while waiting
+	Running(ctx context.Context, taskID uuid.UUID) (context.Context, bool)
+	Done(taskID uuid.UUID, result SubmissionResult)
+	Failed(taskID uuid.UUID, err error)
+	// Cancelled reports if the task was cancelled on request
+	Cancelled(taskID uuid.UUID) bool
+}
 
-		r := SubmissionResult{
-			TaskID: submission.TaskID,
-		}
+// Reporter delivers the result to the postback URL of the submission
+type Reporter interface {
+	Report(submission Submission, result SubmissionResult)
+}
 
-		if err := runContainer(ctx, l, submission, tempDir); err != nil {
-			r.Text = err.Error()
-		} else {
-			r.Text = "OK"
-			r.Pass = true
+func CheckSubmissionJob(
+	exec Executor,
+	cfg Config,
+	submission Submission,
+	tracker Tracker,
+	reporter Reporter,
+) workerpool.Job {
+	return func(parent context.Context) error {
+		ctx, ok := tracker.Running(parent, submission.TaskID)
+		if !ok {
+			// cancelled while waiting, it is already reported
+			return nil
 		}
 
-		sendCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		if err := sendResult(sendCtx, submission.PostbackURL, r); err != nil {
-			return fmt.Errorf("send result: %w", err)
+		r, err := checkSubmission(ctx, exec, cfg, submission)
+		switch {
+		case parent.Err() != nil:
+			// grader is stopping, the task is left running to be reported as interrupted on start
+			return fmt.Errorf("check: %w", parent.Err())
+		case tracker.Cancelled(submission.TaskID):
+			r = CancelledResult(submission.TaskID)
+		case err != nil:
+			tracker.Failed(submission.TaskID, err)
+			return err
 		}
+		tracker.Done(submission.TaskID, r)
+		reporter.Report(submission, r)
 
 		return nil
 	}
 }
 
-func runContainer(ctx context.Context, l logger.Logger, submission Submission, submissionDir string) error {
-	l.Debug().Msg("Creating client")
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	if err != nil {
-		return fmt.Errorf("new client: %w", err)
+// checkSubmission by the executor, errors are returned only if there is no result to report
+func checkSubmission(ctx context.Context, exec Executor, cfg Config, submission Submission) (SubmissionResult, error) {
+	l := logger.Global().WithComponent("CheckSubmissionJob")
+
+	// tasks accepted before the policy was changed are not run either
+	if err := cfg.Images.Check(submission.ContainerImage); err != nil {
+		r := SubmissionResult{TaskID: submission.TaskID, Text: err.Error(), Status: StatusError}
+		return r, nil
 	}
 
-	l.Debug().Str("container_image", submission.ContainerImage).Msg("Pulling image")
-	out, err := cli.ImagePull(ctx, submission.ContainerImage, types.ImagePullOptions{})
+	r, err := prepareAndGrade(ctx, l, exec, cfg, submission)
+	if ctx.Err() != nil {
+		// grader is shutting down, the outcome says nothing about the submission
+		return SubmissionResult{}, fmt.Errorf("grade: %w", ctx.Err())
+	}
 	if err != nil {
-		return fmt.Errorf("image pull: %w", err)
+		r = SubmissionResult{Text: err.Error(), Status: StatusError}
 	}
-	defer func(out io.ReadCloser) {
-		_ = out.Close()
-	}(out)
-	//io.Copy(os.Stdout, out)
-
-	l.Debug().Str("container_image", submission.ContainerImage).Msg("Creating container")
-	resp, err := cli.ContainerCreate(ctx, &container.Config{
-		Image:        submission.ContainerImage,
-		Cmd:          []string{"test", fmt.Sprintf("PART_ID=%s", submission.PartID)},
-		Tty:          true,
-		AttachStdout: true,
-		AttachStderr: true,
-	}, &container.HostConfig{
-		Binds: []string{
-			fmt.Sprintf("%s:/app/submission", submissionDir),
-		},
-	}, nil, nil, "grader_"+submission.TaskID.String())
+	r.TaskID = submission.TaskID
+
+	return r, nil
+}
+
+// prepareAndGrade the submission in its own workspace
+func prepareAndGrade(
+	ctx context.Context,
+	l logger.Logger,
+	exec Executor,
+	cfg Config,
+	submission Submission,
+) (SubmissionResult, error) {
+	ws, err := exec.Prepare(ctx, submission)
 	if err != nil {
-		return fmt.Errorf("container create: %w", err)
+		return SubmissionResult{}, fmt.Errorf("prepare: %w", err)
 	}
+	defer exec.Cleanup(ws)
+	l.Debug().Str("path", ws.SubmissionDir).Msg("Using workspace")
 
-	l.Debug().
-		Str("container_image", submission.ContainerImage).
-		Str("container_id", resp.ID).
-		Msg("Starting container")
-	if err := cli.ContainerStart(ctx, resp.ID, types.ContainerStartOptions{}); err != nil {
-		return fmt.Errorf("container start: %w", err)
+	if err := submission.CheckFiles(); err != nil {
+		return SubmissionResult{}, err
+	}
+	if err := fetchSubmissionFiles(ctx, ws.SubmissionDir, submission.Files); err != nil {
+		return SubmissionResult{}, fmt.Errorf("fetch files: %w", err)
 	}
 
-	l.Debug().
-		Str("container_image", submission.ContainerImage).
-		Str("container_id", resp.ID).
-		Msg("Waiting for container to finish")
-	statusCh, errCh := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	return grade(ctx, l, exec, ws, submission, cfg.Sandbox.Merge(submission.Sandbox))
+}
 
-	var s container.ContainerWaitOKBody
+// grade the submission according to its result mode
+func grade(
+	ctx context.Context,
+	l logger.Logger,
+	exec Executor,
+	ws *Workspace,
+	submission Submission,
+	sandbox Sandbox,
+) (SubmissionResult, error) {
+	if submission.ResultMode == ResultModeIO {
+		return runTestCases(ctx, l, exec, ws, submission, sandbox)
+	}
 
-	select {
-	case err := <-errCh:
-		if err != nil {
-			return fmt.Errorf("container finish: %w", err)
-		}
-	case s = <-statusCh:
-		l.Debug().
-			Str("container_image", submission.ContainerImage).
-			Str("container_id", resp.ID).
-			Int64("container_status", s.StatusCode).
-			Msg("Container failed")
-	}
-
-	l.Debug().
-		Str("container_image", submission.ContainerImage).
-		Str("container_id", resp.ID).
-		Msg("Reading logs")
-	out, err = cli.ContainerLogs(ctx, resp.ID, types.ContainerLogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
-		Timestamps: false,
+	out, err := exec.Run(ctx, ws, RunSpec{
+		Name:    containerName(submission.TaskID),
+		Cmd:     containerCmd(submission),
+		Sandbox: sandbox,
+		Result:  true,
 	})
-	if err != nil {
-		return fmt.Errorf("container out: %w", err)
-	}
-	defer func(logs io.ReadCloser) {
-		_ = logs.Close()
-	}(out)
 
-	buf := new(strings.Builder)
-	_, err = io.Copy(buf, out)
-	if err != nil {
-		return fmt.Errorf("logs: %w", err)
+	var timeoutErr TimeoutError
+	switch {
+	case errors.As(err, &timeoutErr):
+		return SubmissionResult{Text: timeoutErr.Error(), Status: StatusTimeout}, nil
+	case err != nil:
+		return SubmissionResult{}, err
 	}
-	result := buf.String()
 
-	if s.StatusCode > 0 {
-		return ContainerError{result, s.StatusCode}
-	}
+	r := evaluate(l, submission.ResultMode, out, ws.ResultDir)
+	r.ExitCode = &out.StatusCode
 
-	return nil
+	return r, nil
+}
 
+// containerCmd of the grading image, make variables are passed as arguments
+func containerCmd(submission Submission) []string {
+	cmd := []string{"test", fmt.Sprintf("PART_ID=%s", submission.PartID)}
+	if submission.ResultMode == ResultModeGoTest {
+		cmd = append(cmd, "GO_TEST_FLAGS=-json")
+	}
+	return cmd
 }
 
 // fetchSubmissionFiles to target directory
@@ -182,7 +239,7 @@ func fetchSubmissionFiles(ctx context.Context, targetDir string, files []Submiss
 	for _, f := range files {
 		f := f
 		g.Go(func() error {
-			return fetchFile(ctx, f.URL, targetDir+"/"+f.Name)
+			return fetchFile(ctx, f.URL, filepath.Join(targetDir, f.Name))
 		})
 	}
 
@@ -195,6 +252,10 @@ func fetchSubmissionFiles(ctx context.Context, targetDir string, files []Submiss
 
 // fetchFile from URL and save to path
 func fetchFile(ctx context.Context, URL string, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("file dir: %w", err)
+	}
+
 	out, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("file create: %w", err)
@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testImage = "yarcode/grader@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+type testTracker struct {
+	result *SubmissionResult
+	err    error
+}
+
+func (t *testTracker) Running(ctx context.Context, _ uuid.UUID) (context.Context, bool) {
+	return ctx, true
+}
+
+func (t *testTracker) Done(_ uuid.UUID, result SubmissionResult) {
+	t.result = &result
+}
+
+func (t *testTracker) Failed(_ uuid.UUID, err error) {
+	t.err = err
+}
+
+func (t *testTracker) Cancelled(_ uuid.UUID) bool {
+	return false
+}
+
+type testReporter struct {
+	reported int
+}
+
+func (r *testReporter) Report(_ Submission, _ SubmissionResult) {
+	r.reported++
+}
+
+func TestCheckSubmissionJob(t *testing.T) {
+	cfg := Config{Images: ImagePolicy{Allowed: []string{"docker.io/yarcode"}}}
+
+	tests := []struct {
+		name       string
+		submission Submission
+		run        func(ws *Workspace, spec RunSpec) (*ContainerOutput, error)
+		wantStatus string
+		wantScore  float64
+		wantRuns   int
+	}{
+		{
+			name:       "exit code passed",
+			submission: Submission{ContainerImage: testImage, ResultMode: ResultModeExitCode},
+			wantStatus: StatusPassed,
+			wantScore:  1,
+			wantRuns:   1,
+		},
+		{
+			name:       "exit code failed",
+			submission: Submission{ContainerImage: testImage, ResultMode: ResultModeExitCode},
+			run: func(_ *Workspace, _ RunSpec) (*ContainerOutput, error) {
+				return &ContainerOutput{Output: "FAIL", StatusCode: 1}, nil
+			},
+			wantStatus: StatusFailed,
+			wantRuns:   1,
+		},
+		{
+			name:       "json verdict file",
+			submission: Submission{ContainerImage: testImage, ResultMode: ResultModeJSON},
+			run: func(ws *Workspace, spec RunSpec) (*ContainerOutput, error) {
+				if !spec.Result {
+					return nil, errors.New("result dir is not requested")
+				}
+				v := []byte(`{"score": 2, "max_score": 3}`)
+				return &ContainerOutput{}, ioutil.WriteFile(filepath.Join(ws.ResultDir, resultFileName), v, 0644)
+			},
+			wantStatus: StatusFailed,
+			wantScore:  2,
+			wantRuns:   1,
+		},
+		{
+			name: "io test cases",
+			submission: Submission{
+				ContainerImage: testImage,
+				ResultMode:     ResultModeIO,
+				TestCases:      []TestCase{{Input: "1", Expected: "1"}, {Input: "2", Expected: "4"}},
+			},
+			run: func(_ *Workspace, spec RunSpec) (*ContainerOutput, error) {
+				return &ContainerOutput{Output: *spec.Stdin}, nil
+			},
+			wantStatus: StatusFailed,
+			wantScore:  1,
+			wantRuns:   2,
+		},
+		{
+			name:       "timeout",
+			submission: Submission{ContainerImage: testImage},
+			run: func(_ *Workspace, _ RunSpec) (*ContainerOutput, error) {
+				return nil, TimeoutError{Timeout: time.Second}
+			},
+			wantStatus: StatusTimeout,
+			wantRuns:   1,
+		},
+		{
+			name:       "image not allowed",
+			submission: Submission{ContainerImage: "golang:1.17"},
+			wantStatus: StatusError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &FakeExecutor{RunFunc: tt.run}
+			tracker := &testTracker{}
+			reporter := &testReporter{}
+			tt.submission.TaskID = uuid.New()
+
+			err := CheckSubmissionJob(exec, cfg, tt.submission, tracker, reporter)(context.Background())
+			if err != nil || tracker.err != nil {
+				t.Fatalf("job error = %v, tracker error = %v", err, tracker.err)
+			}
+			if tracker.result == nil || reporter.reported != 1 {
+				t.Fatalf("result = %v reported %d times, want reported once", tracker.result, reporter.reported)
+			}
+			if r := tracker.result; r.Status != tt.wantStatus || r.Score != tt.wantScore || r.TaskID != tt.submission.TaskID {
+				t.Errorf("result = %+v, want status %s score %g", r, tt.wantStatus, tt.wantScore)
+			}
+			if got := len(exec.Runs()); got != tt.wantRuns {
+				t.Errorf("runs = %d, want %d", got, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestSubmission_CheckFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		wantErr bool
+	}{
+		{name: "plain", files: []string{"main.go", "README.md"}},
+		{name: "package", files: []string{"hw1/main.go", "hw1/game/game.go"}},
+		{name: "duplicate", files: []string{"main.go", "main.go"}, wantErr: true},
+		{name: "absolute", files: []string{"/etc/passwd"}, wantErr: true},
+		{name: "traversal", files: []string{"../main.go"}, wantErr: true},
+		{name: "unclean", files: []string{"hw1/../main.go"}, wantErr: true},
+		{name: "dir conflict", files: []string{"hw1", "hw1/main.go"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Submission
+			for _, f := range tt.files {
+				s.Files = append(s.Files, SubmissionFile{Name: f, URL: "http://localhost/" + f})
+			}
+			if err := s.CheckFiles(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckFiles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"fmt"
+	"github.com/docker/docker/api/types/container"
+)
+
+// sandboxTmpDir is the tmpfs, the only dir writable by the container with the read-only root
+const sandboxTmpDir = "/tmp"
+
+// sandboxModCache the grading images bake the Go modules in, there is no network to download them
+const sandboxModCache = "/go/pkg/mod"
+
+// sandboxEnv of the container, home and caches of the tools are kept in the tmpfs,
+// modules are read from the image cache
+var sandboxEnv = []string{
+	"HOME=" + sandboxTmpDir,
+	"TMPDIR=" + sandboxTmpDir,
+	"GOCACHE=" + sandboxTmpDir + "/go-cache",
+	"GOMODCACHE=" + sandboxModCache,
+	"GOFLAGS=-mod=mod",
+}
+
+// Sandbox limits applied to the grading container
+type Sandbox struct {
+	NetworkMode    string  `json:"network_mode,omitempty" mapstructure:"network_mode"`
+	User           string  `json:"user,omitempty" mapstructure:"user"`
+	MemoryMB       int64   `json:"memory_mb,omitempty" mapstructure:"memory_mb"`
+	CPUs           float64 `json:"cpus,omitempty" mapstructure:"cpus"`
+	PidsLimit      int64   `json:"pids_limit,omitempty" mapstructure:"pids_limit"`
+	TmpfsMB        int64   `json:"tmpfs_mb,omitempty" mapstructure:"tmpfs_mb"`
+	ReadOnlyRootfs *bool   `json:"read_only_rootfs,omitempty" mapstructure:"read_only_rootfs"`
+	// Timeout of the container run in seconds
+	Timeout int64 `json:"timeout,omitempty" mapstructure:"timeout"`
+}
+
+// Merge non-empty values of the override into a copy of the sandbox
+func (s Sandbox) Merge(o *Sandbox) Sandbox {
+	if o == nil {
+		return s
+	}
+	if o.NetworkMode != "" {
+		s.NetworkMode = o.NetworkMode
+	}
+	if o.User != "" {
+		s.User = o.User
+	}
+	if o.MemoryMB > 0 {
+		s.MemoryMB = o.MemoryMB
+	}
+	if o.CPUs > 0 {
+		s.CPUs = o.CPUs
+	}
+	if o.PidsLimit > 0 {
+		s.PidsLimit = o.PidsLimit
+	}
+	if o.TmpfsMB > 0 {
+		s.TmpfsMB = o.TmpfsMB
+	}
+	if o.ReadOnlyRootfs != nil {
+		s.ReadOnlyRootfs = o.ReadOnlyRootfs
+	}
+	if o.Timeout > 0 {
+		s.Timeout = o.Timeout
+	}
+	return s
+}
+
+// HostConfig for the container, capabilities and privilege escalation are never granted
+func (s Sandbox) HostConfig(binds []string) *container.HostConfig {
+	hc := &container.HostConfig{
+		Binds:       binds,
+		NetworkMode: container.NetworkMode(s.NetworkMode),
+		CapDrop:     []string{"ALL"},
+		SecurityOpt: []string{"no-new-privileges"},
+	}
+
+	if s.ReadOnlyRootfs != nil {
+		hc.ReadonlyRootfs = *s.ReadOnlyRootfs
+	}
+	if s.MemoryMB > 0 {
+		hc.Memory = s.MemoryMB * 1024 * 1024
+		// no swap on top of the memory limit
+		hc.MemorySwap = hc.Memory
+	}
+	if s.CPUs > 0 {
+		hc.NanoCPUs = int64(s.CPUs * 1e9)
+	}
+	if s.PidsLimit > 0 {
+		pids := s.PidsLimit
+		hc.PidsLimit = &pids
+	}
+	if s.TmpfsMB > 0 {
+		hc.Tmpfs = map[string]string{
+			sandboxTmpDir: fmt.Sprintf("rw,nosuid,size=%dm", s.TmpfsMB),
+		}
+	}
+
+	return hc
+}
@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSandbox_Merge(t *testing.T) {
+	readOnly := true
+	writable := false
+
+	defaults := Sandbox{
+		NetworkMode:    "none",
+		User:           "1000:1000",
+		MemoryMB:       512,
+		CPUs:           1,
+		PidsLimit:      256,
+		TmpfsMB:        128,
+		ReadOnlyRootfs: &readOnly,
+		Timeout:        300,
+	}
+
+	tests := []struct {
+		name string
+		o    *Sandbox
+		want Sandbox
+	}{
+		{name: "nil keeps the defaults", o: nil, want: defaults},
+		{name: "empty keeps the defaults", o: &Sandbox{}, want: defaults},
+		{
+			name: "set values override",
+			o:    &Sandbox{NetworkMode: "bridge", MemoryMB: 1024, Timeout: 10, ReadOnlyRootfs: &writable},
+			want: Sandbox{
+				NetworkMode:    "bridge",
+				User:           "1000:1000",
+				MemoryMB:       1024,
+				CPUs:           1,
+				PidsLimit:      256,
+				TmpfsMB:        128,
+				ReadOnlyRootfs: &writable,
+				Timeout:        10,
+			},
+		},
+		{name: "negative values keep the defaults", o: &Sandbox{MemoryMB: -1, CPUs: -1, PidsLimit: -1}, want: defaults},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defaults.Merge(tt.o); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSandbox_HostConfig(t *testing.T) {
+	readOnly := true
+	binds := []string{"/tmp/submission:/app/submission"}
+
+	tests := []struct {
+		name string
+		s    Sandbox
+	}{
+		{name: "empty", s: Sandbox{}},
+		{
+			name: "limited",
+			s:    Sandbox{NetworkMode: "none", MemoryMB: 512, CPUs: 1.5, PidsLimit: 64, TmpfsMB: 128, ReadOnlyRootfs: &readOnly},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hc := tt.s.HostConfig(binds)
+
+			// never granted whatever the sandbox is
+			if !reflect.DeepEqual([]string(hc.CapDrop), []string{"ALL"}) {
+				t.Errorf("CapDrop = %v, want ALL", hc.CapDrop)
+			}
+			if !reflect.DeepEqual(hc.SecurityOpt, []string{"no-new-privileges"}) {
+				t.Errorf("SecurityOpt = %v, want no-new-privileges", hc.SecurityOpt)
+			}
+			if !reflect.DeepEqual(hc.Binds, binds) {
+				t.Errorf("Binds = %v, want %v", hc.Binds, binds)
+			}
+
+			if hc.ReadonlyRootfs != (tt.s.ReadOnlyRootfs != nil && *tt.s.ReadOnlyRootfs) {
+				t.Errorf("ReadonlyRootfs = %v", hc.ReadonlyRootfs)
+			}
+			if string(hc.NetworkMode) != tt.s.NetworkMode {
+				t.Errorf("NetworkMode = %q, want %q", hc.NetworkMode, tt.s.NetworkMode)
+			}
+			if want := tt.s.MemoryMB * 1024 * 1024; hc.Memory != want || hc.MemorySwap != want {
+				t.Errorf("Memory = %d, MemorySwap = %d, want %d", hc.Memory, hc.MemorySwap, want)
+			}
+			if want := int64(tt.s.CPUs * 1e9); hc.NanoCPUs != want {
+				t.Errorf("NanoCPUs = %d, want %d", hc.NanoCPUs, want)
+			}
+			if tt.s.PidsLimit > 0 && (hc.PidsLimit == nil || *hc.PidsLimit != tt.s.PidsLimit) ||
+				tt.s.PidsLimit == 0 && hc.PidsLimit != nil {
+				t.Errorf("PidsLimit = %v, want %d", hc.PidsLimit, tt.s.PidsLimit)
+			}
+			if tt.s.TmpfsMB > 0 && hc.Tmpfs[sandboxTmpDir] != "rw,nosuid,size=128m" ||
+				tt.s.TmpfsMB == 0 && hc.Tmpfs != nil {
+				t.Errorf("Tmpfs = %v", hc.Tmpfs)
+			}
+		})
+	}
+}
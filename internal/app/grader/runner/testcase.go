@@ -0,0 +1,213 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"grader/pkg/logger"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// CompareExact output byte to byte
+	CompareExact = "exact"
+	// CompareTrim ignores trailing spaces of every line and blank lines around the output
+	CompareTrim = "trim"
+	// CompareFloat compares numeric tokens within the tolerance, other tokens exactly
+	CompareFloat = "float"
+)
+
+// defaultTolerance for CompareFloat
+const defaultTolerance = 1e-6
+
+// maxReportOutput of a single output shown in the report
+const maxReportOutput = 1024
+
+// TestCase of the ResultModeIO
+type TestCase struct {
+	Name      string  `json:"name,omitempty"`
+	Input     string  `json:"input"`
+	Expected  string  `json:"expected"`
+	Compare   string  `json:"compare,omitempty"`
+	Tolerance float64 `json:"tolerance,omitempty"`
+	// Weight of the test case in the score, defaults to 1
+	Weight float64 `json:"weight,omitempty"`
+	// Hidden test case does not reveal its input and expected output in the report
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+// MaxScore of the test case
+func (tc TestCase) MaxScore() float64 {
+	if tc.Weight == 0 {
+		return 1
+	}
+	return tc.Weight
+}
+
+// Match actual output against the expected one using the comparison rule
+func (tc TestCase) Match(actual string) bool {
+	switch tc.Compare {
+	case CompareExact:
+		return actual == tc.Expected
+	case CompareFloat:
+		tolerance := tc.Tolerance
+		if tolerance == 0 {
+			tolerance = defaultTolerance
+		}
+		return matchFloat(tc.Expected, actual, tolerance)
+	default:
+		return trimOutput(actual) == trimOutput(tc.Expected)
+	}
+}
+
+func trimOutput(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+func matchFloat(expected, actual string, tolerance float64) bool {
+	want := strings.Fields(expected)
+	got := strings.Fields(actual)
+	if len(want) != len(got) {
+		return false
+	}
+
+	for i := range want {
+		if want[i] == got[i] {
+			continue
+		}
+		w, err := strconv.ParseFloat(want[i], 64)
+		if err != nil {
+			return false
+		}
+		g, err := strconv.ParseFloat(got[i], 64)
+		if err != nil {
+			return false
+		}
+		// NaN and Inf are equal only textually, any arithmetic with them proves nothing
+		if math.IsNaN(g) || math.IsInf(g, 0) || math.IsNaN(w) || math.IsInf(w, 0) {
+			return false
+		}
+		// absolute tolerance for small numbers, relative for large ones
+		if !(math.Abs(w-g) <= tolerance*math.Max(1, math.Abs(w))) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CaseVerdict of a single TestCase run
+type CaseVerdict struct {
+	Pass   bool
+	Reason string
+	Output string
+}
+
+// runTestCases one by one feeding the input and comparing the output
+func runTestCases(
+	ctx context.Context,
+	l logger.Logger,
+	exec Executor,
+	ws *Workspace,
+	submission Submission,
+	sandbox Sandbox,
+) (SubmissionResult, error) {
+	verdicts := make([]CaseVerdict, 0, len(submission.TestCases))
+
+	for i, tc := range submission.TestCases {
+		tc := tc
+		l.Debug().Int("test_case", i+1).Msg("Running test case")
+
+		out, err := exec.Run(ctx, ws, RunSpec{
+			Name:    fmt.Sprintf("%s_%d", containerName(submission.TaskID), i+1),
+			Cmd:     containerCmd(submission),
+			Sandbox: sandbox,
+			Stdin:   &tc.Input,
+		})
+
+		var timeoutErr TimeoutError
+		switch {
+		case errors.As(err, &timeoutErr):
+			verdicts = append(verdicts, CaseVerdict{Reason: "time limit exceeded"})
+		case err != nil:
+			return SubmissionResult{}, fmt.Errorf("test case %d: %w", i+1, err)
+		case out.StatusCode != 0:
+			verdicts = append(verdicts, CaseVerdict{
+				Reason: fmt.Sprintf("runtime error, exit code %d", out.StatusCode),
+				Output: out.Stderr,
+			})
+		case !tc.Match(out.Output):
+			verdicts = append(verdicts, CaseVerdict{Reason: "wrong answer", Output: out.Output})
+		default:
+			verdicts = append(verdicts, CaseVerdict{Pass: true})
+		}
+	}
+
+	return aggregate(submission.TestCases, verdicts), nil
+}
+
+// aggregate test case verdicts into a single result with a readable report
+func aggregate(cases []TestCase, verdicts []CaseVerdict) SubmissionResult {
+	b := new(strings.Builder)
+	passed := 0
+	r := SubmissionResult{
+		Status: StatusFailed,
+		Tests:  make([]TestResult, 0, len(verdicts)),
+	}
+
+	for i, v := range verdicts {
+		tc := cases[i]
+
+		name := fmt.Sprintf("Test #%d", i+1)
+		if tc.Name != "" {
+			name = fmt.Sprintf("%s (%s)", name, tc.Name)
+		}
+
+		r.MaxScore += tc.MaxScore()
+		tr := TestResult{Name: name, Pass: v.Pass, MaxScore: tc.MaxScore(), Message: v.Reason}
+		if v.Pass {
+			tr.Score = tc.MaxScore()
+		}
+		r.Score += tr.Score
+		r.Tests = append(r.Tests, tr)
+
+		if v.Pass {
+			passed++
+			fmt.Fprintf(b, "%s: OK\n", name)
+			continue
+		}
+
+		fmt.Fprintf(b, "%s: FAIL, %s\n", name, v.Reason)
+		if tc.Hidden {
+			continue
+		}
+		fmt.Fprintf(b, "Input:\n%s\n", truncate(tc.Input))
+		fmt.Fprintf(b, "Expected:\n%s\n", truncate(tc.Expected))
+		if v.Output != "" {
+			fmt.Fprintf(b, "Got:\n%s\n", truncate(v.Output))
+		}
+	}
+
+	fmt.Fprintf(b, "\nPassed %d of %d tests", passed, len(verdicts))
+
+	r.Pass = passed == len(verdicts)
+	r.Text = b.String()
+	if r.Pass {
+		r.Status = StatusPassed
+	}
+
+	return r
+}
+
+func truncate(s string) string {
+	if len(s) <= maxReportOutput {
+		return s
+	}
+	return s[:maxReportOutput] + "..."
+}
@@ -0,0 +1,107 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTestCase_Match(t *testing.T) {
+	tests := []struct {
+		name   string
+		tc     TestCase
+		actual string
+		want   bool
+	}{
+		{
+			name:   "trim by default",
+			tc:     TestCase{Expected: "1 2\n3"},
+			actual: "1 2  \r\n3\n\n",
+			want:   true,
+		},
+		{
+			name:   "trim keeps inner spaces",
+			tc:     TestCase{Expected: "1 2"},
+			actual: "1  2",
+			want:   false,
+		},
+		{
+			name:   "exact",
+			tc:     TestCase{Expected: "3", Compare: CompareExact},
+			actual: "3\n",
+			want:   false,
+		},
+		{
+			name:   "float within tolerance",
+			tc:     TestCase{Expected: "0.333333 x", Compare: CompareFloat, Tolerance: 1e-3},
+			actual: "0.3334\nx",
+			want:   true,
+		},
+		{
+			name:   "float out of tolerance",
+			tc:     TestCase{Expected: "0.5", Compare: CompareFloat},
+			actual: "0.51",
+			want:   false,
+		},
+		{
+			name:   "float nan",
+			tc:     TestCase{Expected: "3.14159 42", Compare: CompareFloat, Tolerance: 1e-6},
+			actual: "NaN nan",
+			want:   false,
+		},
+		{
+			name:   "float inf",
+			tc:     TestCase{Expected: "1e308", Compare: CompareFloat, Tolerance: 1},
+			actual: "+Inf",
+			want:   false,
+		},
+		{
+			name:   "float inf expected",
+			tc:     TestCase{Expected: "Inf", Compare: CompareFloat},
+			actual: "inf",
+			want:   false,
+		},
+		{
+			name:   "float same text",
+			tc:     TestCase{Expected: "NaN", Compare: CompareFloat},
+			actual: "NaN",
+			want:   true,
+		},
+		{
+			name:   "float token count mismatch",
+			tc:     TestCase{Expected: "1 2", Compare: CompareFloat},
+			actual: "1",
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tc.Match(tt.actual); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	cases := []TestCase{
+		{Input: "1", Expected: "1"},
+		{Name: "secret", Input: "2", Expected: "4", Hidden: true, Weight: 3},
+	}
+	r := aggregate(cases, []CaseVerdict{
+		{Pass: true},
+		{Reason: "wrong answer", Output: "5"},
+	})
+
+	if r.Pass || r.Status != StatusFailed {
+		t.Errorf("aggregate() = %+v, want failed", r)
+	}
+	if r.Score != 1 || r.MaxScore != 4 || len(r.Tests) != 2 {
+		t.Errorf("aggregate() score = %v of %v with %d tests, want 1 of 4 with 2 tests", r.Score, r.MaxScore, len(r.Tests))
+	}
+	if !strings.HasSuffix(r.Text, "Passed 1 of 2 tests") {
+		t.Errorf("aggregate() text = %q, want summary", r.Text)
+	}
+	if strings.Contains(r.Text, "Got:") {
+		t.Errorf("aggregate() text = %q, hidden test case output revealed", r.Text)
+	}
+}
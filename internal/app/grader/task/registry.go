@@ -0,0 +1,409 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"grader/internal/app/grader/postback"
+	"grader/internal/app/grader/runner"
+	"grader/pkg/apperr"
+	"grader/pkg/logger"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusDone      Status = "done"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Task state of a submission check
+type Task struct {
+	ID         uuid.UUID                `json:"task_id"`
+	Status     Status                   `json:"status"`
+	CreatedAt  time.Time                `json:"created_at"`
+	StartedAt  *time.Time               `json:"started_at,omitempty"`
+	FinishedAt *time.Time               `json:"finished_at,omitempty"`
+	ExitCode   *int64                   `json:"exit_code,omitempty"`
+	Result     *runner.SubmissionResult `json:"result,omitempty"`
+	Error      string                   `json:"error,omitempty"`
+	// Delivery of the result to the postback URL
+	Delivery *postback.Delivery `json:"delivery,omitempty"`
+	// IdempotencyKey supplied by the client, duplicates get this task back
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// Submission being checked, it holds the postback token
+	Submission runner.Submission `json:"-"`
+
+	// cancelled on request, the task is finalized by its job unless it was still waiting
+	cancelled bool
+	cancel    context.CancelFunc
+}
+
+// Finished reports if the task reached its final state
+func (t *Task) Finished() bool {
+	return t.Status == StatusDone || t.Status == StatusFailed || t.Status == StatusCancelled
+}
+
+// runner.Tracker interface implementation
+var _ runner.Tracker = (*Registry)(nil)
+
+// postback.Tracker interface implementation
+var _ postback.Tracker = (*Registry)(nil)
+
+// Registry of the tasks in memory backed by the optional Store, finished tasks are forgotten after the retention period
+type Registry struct {
+	mu        sync.RWMutex
+	tasks     map[uuid.UUID]*Task
+	keys      map[string]uuid.UUID
+	retention time.Duration
+	store     Store
+	logger    logger.Logger
+	now       func() time.Time
+}
+
+type Option func(*Registry)
+
+// WithStore keeps the tasks across restarts
+func WithStore(s Store) Option {
+	return func(r *Registry) {
+		r.store = s
+	}
+}
+
+func NewRegistry(retention time.Duration, opts ...Option) *Registry {
+	r := &Registry{
+		tasks:     make(map[uuid.UUID]*Task),
+		keys:      make(map[string]uuid.UUID),
+		retention: retention,
+		logger:    logger.Global().WithComponent("TaskRegistry"),
+		now:       time.Now,
+	}
+
+	for _, o := range opts {
+		o(r)
+	}
+
+	return r
+}
+
+// Restore tasks of the previous run, queued ones are returned to be resumed and the ones with
+// results to report, including the ones which were running and are failed as interrupted
+func (r *Registry) Restore() (queued []Task, undelivered []Task, err error) {
+	if r.store == nil {
+		return nil, nil, nil
+	}
+
+	tasks, err := r.store.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("load: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range tasks {
+		t := &tasks[i]
+		r.tasks[t.ID] = t
+		if t.IdempotencyKey != "" {
+			r.keys[t.IdempotencyKey] = t.ID
+		}
+
+		switch {
+		case t.Status == StatusQueued:
+			queued = append(queued, *t)
+		case t.Status == StatusRunning:
+			now := r.now()
+			result := runner.InterruptedResult(t.ID)
+			t.Status = StatusFailed
+			t.FinishedAt = &now
+			t.Result = &result
+			t.Error = "interrupted by grader restart"
+			pendingDelivery(t)
+			r.save(t)
+			undelivered = append(undelivered, *t)
+		case t.Result != nil && (t.Delivery == nil || t.Delivery.Status == postback.DeliveryPending):
+			// the result saved without the delivery state was never taken by the outbox
+			undelivered = append(undelivered, *t)
+		}
+	}
+
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].CreatedAt.Before(queued[j].CreatedAt)
+	})
+
+	r.prune()
+
+	return queued, undelivered, nil
+}
+
+// Add a queued task of the submission, it fails if the task can not be persisted
+func (r *Registry) Add(submission runner.Submission) (Task, error) {
+	t, _, err := r.AddIdempotent("", submission)
+	return t, err
+}
+
+// AddIdempotent adds a queued task unless there is one with the same key within the retention period,
+// the existing task is returned then and the flag is false
+func (r *Registry) AddIdempotent(key string, submission runner.Submission) (Task, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune()
+
+	if id, ok := r.keys[key]; ok && key != "" {
+		return *r.tasks[id], false, nil
+	}
+
+	t := &Task{
+		ID:             submission.TaskID,
+		Status:         StatusQueued,
+		CreatedAt:      r.now(),
+		IdempotencyKey: key,
+		Submission:     submission,
+	}
+	if r.store != nil {
+		if err := r.store.Save(*t); err != nil {
+			return Task{}, false, fmt.Errorf("save: %w", err)
+		}
+	}
+	r.tasks[t.ID] = t
+	if key != "" {
+		r.keys[key] = t.ID
+	}
+
+	return *t, true, nil
+}
+
+// Remove the task which was never started
+func (r *Registry) Remove(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.forget(id)
+}
+
+// Get a copy of the task
+func (r *Registry) Get(id uuid.UUID) (Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tasks[id]
+	if !ok {
+		return Task{}, apperr.ErrNotFound
+	}
+
+	return *t, nil
+}
+
+// List tasks in order of creation, all of them if status is empty
+func (r *Registry) List(status Status) []Task {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make([]Task, 0, len(r.tasks))
+	for _, t := range r.tasks {
+		if status != "" && t.Status != status {
+			continue
+		}
+		res = append(res, *t)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+
+	return res
+}
+
+// Cancel the task, the queued one is finalized right away and has to be reported by the caller
+func (r *Registry) Cancel(id uuid.UUID) (Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tasks[id]
+	if !ok {
+		return Task{}, apperr.ErrNotFound
+	}
+	if t.Finished() || t.cancelled {
+		return *t, apperr.ErrConflict
+	}
+
+	t.cancelled = true
+	if t.Status == StatusQueued {
+		now := r.now()
+		result := runner.CancelledResult(id)
+		t.Status = StatusCancelled
+		t.FinishedAt = &now
+		t.Result = &result
+		pendingDelivery(t)
+	}
+	if t.cancel != nil {
+		t.cancel()
+	}
+	r.save(t)
+
+	return *t, nil
+}
+
+// Running implementation of runner.Tracker
+func (r *Registry) Running(ctx context.Context, id uuid.UUID) (context.Context, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tasks[id]
+	if !ok {
+		// not tracked, it can not be cancelled either
+		return ctx, true
+	}
+	if t.cancelled {
+		return ctx, false
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	now := r.now()
+	t.Status = StatusRunning
+	t.StartedAt = &now
+	t.cancel = cancel
+	r.save(t)
+
+	return ctx, true
+}
+
+// Done implementation of runner.Tracker
+func (r *Registry) Done(id uuid.UUID, result runner.SubmissionResult) {
+	r.finish(id, func(t *Task) {
+		t.Status = StatusDone
+		if t.cancelled {
+			t.Status = StatusCancelled
+		}
+		t.ExitCode = result.ExitCode
+		t.Result = &result
+		pendingDelivery(t)
+	})
+}
+
+// Failed implementation of runner.Tracker
+func (r *Registry) Failed(id uuid.UUID, err error) {
+	r.finish(id, func(t *Task) {
+		t.Status = StatusFailed
+		t.Error = err.Error()
+	})
+}
+
+// DeliveryUpdated implementation of postback.Tracker
+func (r *Registry) DeliveryUpdated(id uuid.UUID, d postback.Delivery) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tasks[id]
+	if !ok {
+		return
+	}
+	t.Delivery = &d
+	r.save(t)
+}
+
+// ListDeliveries of the tasks with results in the delivery status
+func (r *Registry) ListDeliveries(status postback.DeliveryStatus) []Task {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res := make([]Task, 0)
+	for _, t := range r.tasks {
+		if t.Delivery != nil && t.Delivery.Status == status {
+			res = append(res, *t)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+
+	return res
+}
+
+// Cancelled implementation of runner.Tracker
+func (r *Registry) Cancelled(id uuid.UUID) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tasks[id]
+	return ok && t.cancelled
+}
+
+// finish the task releasing its context
+func (r *Registry) finish(id uuid.UUID, fn func(t *Task)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tasks[id]
+	if !ok {
+		return
+	}
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+
+	now := r.now()
+	t.FinishedAt = &now
+	fn(t)
+	r.save(t)
+}
+
+// pendingDelivery of the result is saved along with it, so the result finished right before
+// a restart is delivered even if the outbox has not taken it yet
+func pendingDelivery(t *Task) {
+	if t.Delivery == nil {
+		t.Delivery = &postback.Delivery{Status: postback.DeliveryPending}
+	}
+}
+
+// prune finished tasks past the retention period, must be called under the lock
+func (r *Registry) prune() {
+	if r.retention <= 0 {
+		return
+	}
+
+	deadline := r.now().Add(-r.retention)
+	for id, t := range r.tasks {
+		// undelivered results are kept until they are delivered or replayed
+		if t.Finished() && t.FinishedAt.Before(deadline) && !t.Delivery.Undelivered() {
+			r.forget(id)
+		}
+	}
+}
+
+// forget the task and its key, must be called under the lock
+func (r *Registry) forget(id uuid.UUID) {
+	if t, ok := r.tasks[id]; ok && t.IdempotencyKey != "" {
+		delete(r.keys, t.IdempotencyKey)
+	}
+	delete(r.tasks, id)
+	r.delete(id)
+}
+
+// save the task state, failures are logged as the in memory state is still valid
+func (r *Registry) save(t *Task) {
+	if r.store == nil {
+		return
+	}
+	if err := r.store.Save(*t); err != nil {
+		r.logger.Error().Err(err).Str("task_id", t.ID.String()).Msg("Unable to save task")
+	}
+}
+
+func (r *Registry) delete(id uuid.UUID) {
+	if r.store == nil {
+		return
+	}
+	if err := r.store.Delete(id); err != nil {
+		r.logger.Error().Err(err).Str("task_id", id.String()).Msg("Unable to delete task")
+	}
+}
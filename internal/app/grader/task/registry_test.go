@@ -0,0 +1,181 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"grader/internal/app/grader/postback"
+	"grader/internal/app/grader/runner"
+	"grader/pkg/apperr"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	now := time.Now()
+	r := NewRegistry(time.Hour)
+	r.now = func() time.Time { return now }
+
+	done := uuid.New()
+	failed := uuid.New()
+	queued := uuid.New()
+
+	r.Add(runner.Submission{TaskID: done})
+	r.Running(context.Background(), done)
+	r.Done(done, runner.SubmissionResult{Pass: true})
+	r.Add(runner.Submission{TaskID: failed})
+	r.Failed(failed, errors.New("oops"))
+	now = now.Add(time.Minute)
+	r.Add(runner.Submission{TaskID: queued})
+
+	if got := r.List(""); len(got) != 3 {
+		t.Errorf("List() got %d tasks, want 3", len(got))
+	}
+	if got := r.List(StatusQueued); len(got) != 1 || got[0].ID != queued {
+		t.Errorf("List(queued) = %v, want %s only", got, queued)
+	}
+
+	got, err := r.Get(done)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Status != StatusDone || got.StartedAt == nil || got.Result == nil || !got.Result.Pass {
+		t.Errorf("Get() = %+v, want done with result", got)
+	}
+
+	// finished tasks expire, the queued one stays
+	now = now.Add(2 * time.Hour)
+	r.Add(runner.Submission{TaskID: uuid.New()})
+	if _, err := r.Get(failed); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("Get() error = %v, want %v", err, apperr.ErrNotFound)
+	}
+	if _, err := r.Get(queued); err != nil {
+		t.Errorf("Get() error = %v, want queued task kept", err)
+	}
+}
+
+func TestRegistry_Cancel(t *testing.T) {
+	r := NewRegistry(time.Hour)
+
+	queued := uuid.New()
+	running := uuid.New()
+	r.Add(runner.Submission{TaskID: queued})
+	r.Add(runner.Submission{TaskID: running})
+	ctx, _ := r.Running(context.Background(), running)
+
+	if got, err := r.Cancel(queued); err != nil || got.Status != StatusCancelled {
+		t.Errorf("Cancel() = %v, %v, want cancelled", got.Status, err)
+	}
+	if _, ok := r.Running(context.Background(), queued); ok {
+		t.Errorf("Running() of cancelled task = true, want false")
+	}
+
+	if got, err := r.Cancel(running); err != nil || got.Status != StatusRunning {
+		t.Errorf("Cancel() = %v, %v, want running", got.Status, err)
+	}
+	if ctx.Err() == nil || !r.Cancelled(running) {
+		t.Errorf("Cancel() did not cancel the running task context")
+	}
+	r.Done(running, runner.CancelledResult(running))
+	if got, _ := r.Get(running); got.Status != StatusCancelled {
+		t.Errorf("Get() status = %v, want %v", got.Status, StatusCancelled)
+	}
+
+	if _, err := r.Cancel(running); !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("Cancel() error = %v, want %v", err, apperr.ErrConflict)
+	}
+	if _, err := r.Cancel(uuid.New()); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("Cancel() error = %v, want %v", err, apperr.ErrNotFound)
+	}
+}
+
+func TestRegistry_Restore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	queued := uuid.New()
+	running := uuid.New()
+	done := uuid.New()
+	pending := uuid.New()
+	// finished right before the restart, the outbox never took it
+	unreported := uuid.New()
+
+	prev := NewRegistry(time.Hour, WithStore(store))
+	for _, id := range []uuid.UUID{queued, running, done, pending, unreported} {
+		if _, err := prev.Add(runner.Submission{TaskID: id, PostbackURL: "http://panel/" + id.String()}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	prev.Running(context.Background(), running)
+	prev.Running(context.Background(), done)
+	prev.Done(done, runner.SubmissionResult{Pass: true})
+	prev.DeliveryUpdated(done, postback.Delivery{Status: postback.DeliveryDelivered})
+	prev.Running(context.Background(), pending)
+	prev.Done(pending, runner.SubmissionResult{Pass: true})
+	prev.DeliveryUpdated(pending, postback.Delivery{Status: postback.DeliveryPending, Attempts: 2})
+	prev.Running(context.Background(), unreported)
+	prev.Done(unreported, runner.SubmissionResult{Pass: true})
+
+	r := NewRegistry(time.Hour, WithStore(store))
+	gotQueued, gotUndelivered, err := r.Restore()
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if len(gotQueued) != 1 || gotQueued[0].ID != queued || gotQueued[0].Submission.TaskID != queued {
+		t.Errorf("Restore() queued = %+v, want %s", gotQueued, queued)
+	}
+	if gotQueued[0].Submission.PostbackURL != "http://panel/"+queued.String() {
+		t.Errorf("Restore() queued submission = %+v, want postback URL kept", gotQueued[0].Submission)
+	}
+	if len(gotUndelivered) != 3 {
+		t.Fatalf("Restore() got %d undelivered, want 3", len(gotUndelivered))
+	}
+	for _, u := range gotUndelivered {
+		switch u.ID {
+		case running:
+			if u.Result.Status != runner.StatusInterrupted {
+				t.Errorf("Restore() interrupted result = %+v", u.Result)
+			}
+		case pending:
+			if u.Delivery.Attempts != 2 {
+				t.Errorf("Restore() pending delivery = %+v", u.Delivery)
+			}
+		case unreported:
+			if u.Result == nil || !u.Result.Pass || !u.Delivery.Undelivered() {
+				t.Errorf("Restore() unreported = %+v, want pending result", u)
+			}
+		default:
+			t.Errorf("Restore() unexpected undelivered %s", u.ID)
+		}
+	}
+	if got, err := r.Get(done); err != nil || got.Status != StatusDone {
+		t.Errorf("Get() = %v, %v, want done", got.Status, err)
+	}
+}
+
+func TestRegistry_AddIdempotent(t *testing.T) {
+	now := time.Now()
+	r := NewRegistry(time.Hour)
+	r.now = func() time.Time { return now }
+
+	first, created, err := r.AddIdempotent("key", runner.Submission{TaskID: uuid.New()})
+	if err != nil || !created {
+		t.Fatalf("AddIdempotent() = %v, %v, want created", created, err)
+	}
+
+	dup, created, err := r.AddIdempotent("key", runner.Submission{TaskID: uuid.New()})
+	if err != nil || created || dup.ID != first.ID {
+		t.Errorf("AddIdempotent() = %s, %v, %v, want existing %s", dup.ID, created, err, first.ID)
+	}
+
+	// key is released along with the expired task
+	r.Failed(first.ID, errors.New("oops"))
+	now = now.Add(2 * time.Hour)
+	next, created, err := r.AddIdempotent("key", runner.Submission{TaskID: uuid.New()})
+	if err != nil || !created || next.ID == first.ID {
+		t.Errorf("AddIdempotent() = %s, %v, %v, want new task", next.ID, created, err)
+	}
+}
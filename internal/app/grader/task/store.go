@@ -0,0 +1,130 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"grader/internal/app/grader/runner"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store of the tasks surviving grader restarts
+type Store interface {
+	// Save the task replacing the previous state
+	Save(t Task) error
+	// Delete the task, missing one is not an error
+	Delete(id uuid.UUID) error
+	// Load all saved tasks
+	Load() ([]Task, error)
+}
+
+// record of the task as it is stored, unlike the API view it keeps the submission
+type record struct {
+	Task
+	Submission runner.Submission `json:"submission"`
+	Cancelled  bool              `json:"cancelled,omitempty"`
+}
+
+const taskFileExt = ".json"
+
+// Store interface implementation
+var _ Store = (*FileStore)(nil)
+
+// FileStore keeps every task in its own JSON file
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	// files hold postback tokens
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("mkdir: %w", err)
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+// Save implementation of interface Store
+func (s *FileStore) Save(t Task) error {
+	b, err := json.Marshal(&record{Task: t, Submission: t.Submission, Cancelled: t.cancelled})
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	// written aside and renamed so a crash never leaves a torn file
+	f, err := ioutil.TempFile(s.dir, "tmp*")
+	if err != nil {
+		return fmt.Errorf("temp file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("sync: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), s.path(t.ID)); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+
+	return nil
+}
+
+// Delete implementation of interface Store
+func (s *FileStore) Delete(id uuid.UUID) error {
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove: %w", err)
+	}
+
+	return nil
+}
+
+// Load implementation of interface Store
+func (s *FileStore) Load() ([]Task, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read dir: %w", err)
+	}
+
+	res := make([]Task, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), taskFileExt) {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", e.Name(), err)
+		}
+
+		rec := &record{}
+		if err := json.Unmarshal(b, rec); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", e.Name(), err)
+		}
+
+		t := rec.Task
+		t.Submission = rec.Submission
+		t.Submission.TaskID = t.ID
+		t.cancelled = rec.Cancelled
+		res = append(res, t)
+	}
+
+	return res, nil
+}
+
+func (s *FileStore) path(id uuid.UUID) string {
+	return filepath.Join(s.dir, id.String()+taskFileExt)
+}
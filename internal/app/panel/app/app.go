@@ -10,6 +10,7 @@ import (
 	"grader/internal/app/panel/config"
 	"grader/internal/app/panel/handler"
 	"grader/internal/app/panel/pkg/auth"
+	"grader/internal/app/panel/pkg/grader"
 	"grader/internal/app/panel/storage/postgres"
 	"grader/internal/pkg/migrate"
 	"grader/pkg/aws"
@@ -24,6 +25,7 @@ import (
 	"grader/pkg/workerpool"
 	"grader/web"
 	"net/http"
+	"net/url"
 	"runtime"
 	"time"
 )
@@ -72,6 +74,12 @@ func New(cfg config.Config) (*App, error) {
 		return nil, fmt.Errorf("token manager: %w", err)
 	}
 
+	// callback tokens travel through the queue and the grader, they must not pass for the session ones
+	ctm, err := token.NewAudienceJWT(cfg.Security.SecretKey, "callback")
+	if err != nil {
+		return nil, fmt.Errorf("callback token manager: %w", err)
+	}
+
 	sm := session.NewRedis(
 		rds,
 		tm,
@@ -93,7 +101,11 @@ func New(cfg config.Config) (*App, error) {
 	}
 	submissions, err := postgres.NewSubmissionRepository(db)
 	if err != nil {
-		return nil, fmt.Errorf("assessments repository: %w", err)
+		return nil, fmt.Errorf("submissions repository: %w", err)
+	}
+	graders, err := postgres.NewGraderRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("graders repository: %w", err)
 	}
 
 	r := chi.NewRouter()
@@ -110,11 +122,28 @@ func New(cfg config.Config) (*App, error) {
 	}
 
 	uh := handler.NewUserHandler(lt, sm, users)
-	ah := handler.NewAdminHandler(lt, users, assessments)
-	sh, err := handler.NewSubmitHandler(lt, s3, q, cfg.App.TopicName, users, assessments, submissions)
+	gr := grader.NewRegistry(grader.NewClient(cfg.Grader.URL, cfg.Grader.Secret), graders)
+
+	if u, err := url.Parse(cfg.HTTP.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("http public url: absolute http(s) URL is expected, got %q", cfg.HTTP.PublicURL)
+	}
+	ah := handler.NewAdminHandler(lt, users, assessments, submissions, graders, gr, cfg.HTTP.PublicURL)
+	sh, err := handler.NewSubmitHandler(
+		lt,
+		s3,
+		q,
+		cfg.App.TopicName,
+		ctm,
+		cfg.Security.CallbackTokenLifetime,
+		gr,
+		users,
+		assessments,
+		submissions,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("submission handler: %w", err)
 	}
+	rh := handler.NewResultHandler(ctm, submissions)
 
 	r.Route("/app", func(r chi.Router) {
 		r.Use(session.ContextMiddleware(sm))
@@ -135,20 +164,43 @@ func New(cfg config.Config) (*App, error) {
 			r.Post("/register", uh.Register)
 
 			r.Get("/logout", uh.Logout)
+
+			r.With(auth.AuthMiddleware()).Get("/submissions", sh.List)
+			r.With(auth.AuthMiddleware()).Get("/submissions/{id}/logs", sh.Logs)
+			r.With(auth.AuthMiddleware()).Get("/submissions/{id}/logs/stream", sh.LogsStream)
 		})
 
 		r.Route("/admin", func(r chi.Router) {
-			r.Use(auth.AuthMiddleware())
+			r.Use(auth.AuthMiddleware(), auth.AdminMiddleware())
 
 			r.Get("/assessments", ah.AssessmentList)
+			r.Get("/submissions", ah.SubmissionList)
 
 			r.Get("/assessments/create", ah.AssessmentCreate)
 			r.Post("/assessments/create", ah.AssessmentCreate)
+			r.Get("/assessments/{id}/edit", ah.AssessmentEdit)
+			r.Post("/assessments/{id}/edit", ah.AssessmentEdit)
+			r.Post("/assessments/{id}/archive", ah.AssessmentArchive)
+			r.Get("/assessments/{id}/delete", ah.AssessmentDelete)
+			r.Post("/assessments/{id}/delete", ah.AssessmentDelete)
+
+			r.Post("/assessments/prewarm", ah.AssessmentPrewarm)
+
+			r.Get("/graders", ah.GraderList)
+			r.Get("/graders/create", ah.GraderCreate)
+			r.Post("/graders/create", ah.GraderCreate)
+			r.Get("/graders/{id}", ah.GraderEdit)
+			r.Post("/graders/{id}", ah.GraderEdit)
 		})
 
 		r.Get("/", uh.Default)
 	})
 
+	r.Route("/api", func(r chi.Router) {
+		r.Post("/submissions/result", rh.Create)
+		r.Post("/submissions/{id}/result", rh.Create)
+	})
+
 	static := http.FileServer(http.FS(web.StaticFS))
 	r.Handle("/static/*", static)
 
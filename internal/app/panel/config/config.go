@@ -5,17 +5,20 @@ import (
 	"grader/pkg/httpserver"
 	"grader/pkg/logger"
 	"grader/pkg/queue/amqp"
+	"time"
 )
 
 type Config struct {
 	App      AppConfig         `mapstructure:"app"`
 	Server   httpserver.Config `mapstructure:"server"`
+	HTTP     HTTPConfig        `mapstructure:"http"`
 	DB       DatabaseConfig    `mapstructure:"db"`
 	AMQP     amqp.Config       `mapstructure:"amqp"`
 	Logger   logger.Config     `mapstructure:"log"`
 	AWS      aws.Config        `mapstructure:"aws"`
 	Redis    RedisConfig       `mapstructure:"redis"`
 	Security SecurityConfig    `mapstructure:"security"`
+	Grader   GraderConfig      `mapstructure:"grader"`
 }
 
 type AppConfig struct {
@@ -23,6 +26,11 @@ type AppConfig struct {
 	TopicName string `mapstructure:"topic_name"`
 }
 
+type HTTPConfig struct {
+	// PublicURL of the panel as the students reach it, the shared submission links are built upon it
+	PublicURL string `mapstructure:"public_url"`
+}
+
 type DatabaseConfig struct {
 	DSN string `mapstructure:"dsn"`
 }
@@ -34,5 +42,16 @@ type RedisConfig struct {
 }
 
 type SecurityConfig struct {
+	// SecretKey signing the session and callback tokens, at least token.MinSecretKeyLength bytes
 	SecretKey string `mapstructure:"secret_key"`
+	// CallbackTokenLifetime counted from the submission, it has to cover the queue wait, the grading
+	// and the grader postback retries, results replayed by the grader after it are rejected
+	CallbackTokenLifetime time.Duration `mapstructure:"callback_token_lifetime"`
+}
+
+// GraderConfig of the default grader, assessments may be assigned to the registered ones instead
+type GraderConfig struct {
+	URL string `mapstructure:"url"`
+	// Secret presented to the grader as the bearer token, no token is sent if empty
+	Secret string `mapstructure:"secret"`
 }
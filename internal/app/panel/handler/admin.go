@@ -1,23 +1,60 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"grader/internal/app/panel/pkg/grader"
 	"grader/internal/app/panel/storage"
 	"grader/internal/pkg/model"
 	"grader/pkg/apperr"
+	"grader/pkg/httpserver"
 	"grader/pkg/httputil"
 	"grader/pkg/layout"
 	"grader/pkg/logger"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// lateWriteTimeout of the response written after the grader pulls
+const lateWriteTimeout = 10 * time.Second
+
 type AdminHandler struct {
 	layout      *layout.Layout
 	users       storage.UserRepository
 	assessments storage.AssessmentRepository
+	submissions storage.SubmissionRepository
+	graders     storage.GraderRepository
+	registry    *grader.Registry
+	// publicURL of the panel, links shared with students are built upon it
+	publicURL string
 }
 
-func NewAdminHandler(l *layout.Layout, u storage.UserRepository, a storage.AssessmentRepository) *AdminHandler {
-	return &AdminHandler{layout: l, users: u, assessments: a}
+func NewAdminHandler(
+	l *layout.Layout,
+	u storage.UserRepository,
+	a storage.AssessmentRepository,
+	s storage.SubmissionRepository,
+	g storage.GraderRepository,
+	reg *grader.Registry,
+	publicURL string,
+) *AdminHandler {
+	return &AdminHandler{
+		layout:      l,
+		users:       u,
+		assessments: a,
+		submissions: s,
+		graders:     g,
+		registry:    reg,
+		publicURL:   strings.TrimSuffix(publicURL, "/"),
+	}
 }
 
 func (h *AdminHandler) AssessmentList(w http.ResponseWriter, r *http.Request) {
@@ -33,7 +70,8 @@ func (h *AdminHandler) AssessmentList(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := map[string]interface{}{
-		"Models": models,
+		"Models":  models,
+		"BaseURL": h.publicURL,
 	}
 
 	h.layout.RenderView(w, r, "template/app/views/admin/assessment_list.gohtml", data)
@@ -44,38 +82,330 @@ func (h *AdminHandler) AssessmentCreate(w http.ResponseWriter, r *http.Request)
 	l := logger.Ctx(ctx)
 
 	if r.Method != http.MethodPost {
-		h.layout.RenderView(w, r, "template/app/views/admin/assessment_create.gohtml", nil)
+		data := map[string]interface{}{
+			"Example": exampleGraderConfig,
+		}
+		h.layout.RenderView(w, r, "template/app/views/admin/assessment_form.gohtml", data)
+		return
+	}
+
+	m := &model.Assessment{}
+	if !h.assessmentForm(w, r, m) {
+		return
+	}
+
+	if _, err := h.assessments.Create(ctx, m); err != nil {
+		if errors.Is(err, apperr.ErrConflict) {
+			httputil.WriteError(w, fmt.Errorf("part id %s: %w", m.PartID, err), http.StatusConflict)
+			return
+		}
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/app/admin/assessments", http.StatusFound)
+}
+
+// AssessmentEdit of the grader config and the summary, the image is pinned again if it is changed
+func (h *AdminHandler) AssessmentEdit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	m, ok := h.assessment(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		config, err := json.MarshalIndent(m.GraderConfig(), "", "  ")
+		if err != nil {
+			l.Error().Err(err).Send()
+			httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+			return
+		}
+
+		data := map[string]interface{}{
+			"Model":   m,
+			"Config":  string(config),
+			"Example": exampleGraderConfig,
+			"BaseURL": h.publicURL,
+		}
+		h.layout.RenderView(w, r, "template/app/views/admin/assessment_form.gohtml", data)
+		return
+	}
+
+	if !h.assessmentForm(w, r, m) {
+		return
+	}
+
+	if _, err := h.assessments.Update(ctx, m); err != nil {
+		if errors.Is(err, apperr.ErrConflict) {
+			httputil.WriteError(w, fmt.Errorf("part id %s: %w", m.PartID, err), http.StatusConflict)
+			return
+		}
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/app/admin/assessments", http.StatusFound)
+}
+
+// AssessmentArchive hides the assessment from students or restores it, the submissions are kept
+func (h *AdminHandler) AssessmentArchive(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	m, ok := h.assessment(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.assessments.SetArchived(ctx, m.ID, r.FormValue("archived") != ""); err != nil {
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/app/admin/assessments", http.StatusFound)
+}
+
+// AssessmentDelete refused while there are submissions unless the admin types their count, archiving keeps
+// them instead
+func (h *AdminHandler) AssessmentDelete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	m, ok := h.assessment(w, r)
+	if !ok {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		count, err := h.submissions.CountByAssessmentID(ctx, m.ID)
+		if err != nil {
+			l.Error().Err(err).Send()
+			httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+			return
+		}
+
+		data := map[string]interface{}{
+			"Model":       m,
+			"Submissions": count,
+		}
+		h.layout.RenderView(w, r, "template/app/views/admin/assessment_delete.gohtml", data)
 		return
 	}
 
+	confirmed := 0
+	if v := strings.TrimSpace(r.FormValue("submissions")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid submissions count", http.StatusBadRequest)
+			return
+		}
+		confirmed = n
+	}
+
+	if err := h.assessments.Delete(ctx, m.ID, confirmed); err != nil {
+		if errors.Is(err, apperr.ErrConflict) {
+			httputil.WriteError(w, fmt.Errorf("%w, archive it or type the count of its submissions to delete them too", err), http.StatusConflict)
+			return
+		}
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/app/admin/assessments", http.StatusFound)
+}
+
+// assessment of the URL, the error response is written if it is not found
+func (h *AdminHandler) assessment(w http.ResponseWriter, r *http.Request) (*model.Assessment, bool) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	m, err := h.assessments.Read(ctx, id)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			http.Error(w, "Assessment not found", http.StatusNotFound)
+			return nil, false
+		}
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return m, true
+}
+
+// assessmentForm applied to the assessment, the image is resolved to the digest unless it is pinned already
+func (h *AdminHandler) assessmentForm(w http.ResponseWriter, r *http.Request, m *model.Assessment) bool {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	r.Body = http.MaxBytesReader(w, r.Body, 2*model.MaxGraderConfigSize)
+	if err := r.ParseMultipartForm(model.MaxGraderConfigSize); err != nil {
+		httputil.WriteError(w, fmt.Errorf("%w: form: %v", apperr.ErrInvalidInput, err), http.StatusBadRequest)
+		return false
+	}
+	defer func(form *multipart.Form) {
+		_ = form.RemoveAll()
+	}(r.MultipartForm)
+
 	in := &struct {
-		PartID         string `validate:"required"`
-		ContainerImage string `validate:"required"`
-		Summary        string `validate:"required"`
-		FileName       string `validate:"required"`
+		Summary string `validate:"required"`
 	}{
-		r.FormValue("part_id"),
-		r.FormValue("container_image"),
 		r.FormValue("summary"),
-		r.FormValue("file_name"),
 	}
 
 	if !httputil.ValidateData(w, in) {
+		return false
+	}
+
+	cfg, err := graderConfigForm(r)
+	if err != nil {
+		writeGraderConfigError(w, err)
+		return false
+	}
+
+	m.Summary = in.Summary
+	cfg.Apply(m)
+
+	gc, err := h.registry.Client(ctx, m.ExternalGrader)
+	if err != nil {
+		if errors.Is(err, grader.ErrNotRegistered) {
+			msg := fmt.Sprintf("grader %q is not registered", m.ExternalGrader)
+			writeGraderConfigError(w, model.ConfigErrors{{Path: "external_grader", Msg: msg}})
+			return false
+		}
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return false
+	}
+
+	if m.ContainerImageDigest != "" {
+		return true
+	}
+
+	// tags may be moved while the course is running, so submissions are graded by the digest
+	m.ContainerImageDigest, err = gc.ResolveImage(ctx, m.ContainerImage)
+	// grader pull may take longer than the server write timeout
+	_ = httpserver.ExtendWriteDeadline(r, lateWriteTimeout)
+	if err != nil {
+		if errors.Is(err, apperr.ErrInvalidInput) {
+			httputil.WriteError(w, fmt.Errorf("container image: %w", err), http.StatusBadRequest)
+			return false
+		}
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, fmt.Errorf("container image: %w", err), http.StatusBadGateway)
+		return false
+	}
+
+	return true
+}
+
+// exampleGraderConfig shown on the form, it follows the requirements document
+const exampleGraderConfig = `{
+  "external_grader": "",
+  "files": [
+    {"label": "hw1_game/main.go", "filename": "main.go", "max_size": 65536}
+  ],
+  "grader_payload": {
+    "container": "docker.io/yarcode/golangcourse_final:latest",
+    "partId": "HW1_game",
+    "result_mode": "exit_code",
+    "sandbox": {"network_mode": "none", "memory_mb": 256, "timeout": 60}
+  }
+}`
+
+// graderConfigForm document, the uploaded file takes precedence over the pasted text
+func graderConfigForm(r *http.Request) (*model.GraderConfig, error) {
+	data := []byte(r.FormValue("grader_config"))
+
+	f, _, err := r.FormFile("grader_config_file")
+	switch {
+	case err == nil:
+		defer func(f multipart.File) {
+			_ = f.Close()
+		}(f)
+		data, err = ioutil.ReadAll(io.LimitReader(f, model.MaxGraderConfigSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("grader config file: %w", err)
+		}
+		if len(data) > model.MaxGraderConfigSize {
+			return nil, model.ConfigErrors{{Msg: fmt.Sprintf("document exceeds %d bytes", model.MaxGraderConfigSize)}}
+		}
+	case !errors.Is(err, http.ErrMissingFile):
+		return nil, fmt.Errorf("grader config file: %w", err)
+	}
+
+	return model.ParseGraderConfig(data)
+}
+
+// writeGraderConfigError as validation errors pointing to the document paths
+func writeGraderConfigError(w http.ResponseWriter, err error) {
+	var configErrs model.ConfigErrors
+	if !errors.As(err, &configErrs) {
+		httputil.WriteError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	m := &model.Assessment{
-		PartID:         in.PartID,
-		ContainerImage: in.ContainerImage,
-		Summary:        in.Summary,
-		FileName:       in.FileName,
+	errs := make(httputil.ValidationErrors, 0, len(configErrs))
+	for _, e := range configErrs {
+		param := "grader_config"
+		if e.Path != "" {
+			param += "." + e.Path
+		}
+		errs = append(errs, httputil.ValidationError{Msg: e.Msg, Param: param})
 	}
+	httputil.WriteValidationErrors(w, errs)
+}
 
-	_, err := h.assessments.Create(ctx, m)
+// AssessmentPrewarm pulls the images of the assessments on the grader host
+func (h *AdminHandler) AssessmentPrewarm(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	models, err := h.assessments.All(ctx)
 	if err != nil {
 		l.Error().Err(err).Send()
 		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
 	}
 
-	http.Redirect(w, r, "/app/admin/assessments", http.StatusFound)
+	results := h.registry.Prewarm(ctx, models)
+	// grader pulls may take longer than the server write timeout
+	_ = httpserver.ExtendWriteDeadline(r, lateWriteTimeout)
+
+	data := map[string]interface{}{
+		"Results": results,
+	}
+
+	h.layout.RenderView(w, r, "template/app/views/admin/assessment_prewarm.gohtml", data)
+}
+
+func (h *AdminHandler) SubmissionList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	models, err := h.submissions.All(ctx)
+	if err != nil {
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Models": models,
+	}
+
+	h.layout.RenderView(w, r, "template/app/views/admin/submission_list.gohtml", data)
 }
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"grader/internal/pkg/model"
+	"grader/pkg/apperr"
+	"grader/pkg/httputil"
+	"grader/pkg/logger"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GraderList of the registered graders
+func (h *AdminHandler) GraderList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	models, err := h.graders.All(ctx)
+	if err != nil {
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Models": models,
+	}
+
+	h.layout.RenderView(w, r, "template/app/views/admin/grader_list.gohtml", data)
+}
+
+// GraderCreate registers a new grader
+func (h *AdminHandler) GraderCreate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	if r.Method != http.MethodPost {
+		data := map[string]interface{}{
+			"Model": &model.Grader{Enabled: true},
+		}
+		h.layout.RenderView(w, r, "template/app/views/admin/grader_form.gohtml", data)
+		return
+	}
+
+	m, ok := graderForm(w, r, &model.Grader{Name: strings.TrimSpace(r.FormValue("name"))})
+	if !ok {
+		return
+	}
+
+	if _, err := h.graders.Create(ctx, m); err != nil {
+		if errors.Is(err, apperr.ErrConflict) {
+			httputil.WriteError(w, err, http.StatusConflict)
+			return
+		}
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/app/admin/graders", http.StatusFound)
+}
+
+// GraderEdit settings of the grader, the name is kept as assessments refer to it
+func (h *AdminHandler) GraderEdit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	m, err := h.graders.Read(ctx, id)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			http.Error(w, "Grader not found", http.StatusNotFound)
+			return
+		}
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		data := map[string]interface{}{
+			"Model": m,
+		}
+		h.layout.RenderView(w, r, "template/app/views/admin/grader_form.gohtml", data)
+		return
+	}
+
+	m, ok := graderForm(w, r, m)
+	if !ok {
+		return
+	}
+
+	if _, err := h.graders.Update(ctx, m); err != nil {
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/app/admin/graders", http.StatusFound)
+}
+
+// graderForm settings applied to the grader, the secret is kept if it is left empty
+func graderForm(w http.ResponseWriter, r *http.Request, m *model.Grader) (*model.Grader, bool) {
+	in := &struct {
+		URL            string `validate:"required,url"`
+		MaxConcurrency string `validate:"omitempty,number"`
+	}{
+		strings.TrimSpace(r.FormValue("url")),
+		r.FormValue("max_concurrency"),
+	}
+
+	if !httputil.ValidateData(w, in) {
+		return nil, false
+	}
+
+	// validated to be a number above
+	maxConcurrency, _ := strconv.Atoi(in.MaxConcurrency)
+
+	m.URL = in.URL
+	m.Enabled = r.FormValue("enabled") != ""
+	m.MaxConcurrency = maxConcurrency
+	if secret := r.FormValue("secret"); secret != "" {
+		m.Secret = secret
+	}
+	if r.FormValue("clear_secret") != "" {
+		m.Secret = ""
+	}
+
+	if err := m.Validate(); err != nil {
+		httputil.WriteError(w, err, http.StatusBadRequest)
+		return nil, false
+	}
+
+	return m, true
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"grader/internal/app/panel/storage"
+	"grader/internal/pkg/graderapi"
+	"grader/internal/pkg/model"
+	"grader/pkg/apperr"
+	"grader/pkg/httputil"
+	"grader/pkg/logger"
+	"grader/pkg/token"
+	"net/http"
+	"strings"
+)
+
+const bearerPrefix = "Bearer "
+
+type ResultHandler struct {
+	tokens      token.Manager
+	submissions storage.SubmissionRepository
+}
+
+func NewResultHandler(tm token.Manager, s storage.SubmissionRepository) *ResultHandler {
+	return &ResultHandler{tokens: tm, submissions: s}
+}
+
+// Create records grader verdict for the submission the callback token is issued for
+func (h *ResultHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	// token must be issued for this very submission and not expired yet, nothing is looked up before that
+	id, err := h.subject(r)
+	if err != nil {
+		l.Debug().Err(err).Msg("Bad callback token")
+		httputil.WriteError(w, apperr.ErrUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	in := &graderapi.SubmissionResult{}
+
+	if err := httputil.ReadBody(r, in); err != nil {
+		httputil.WriteError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	m, err := h.submissions.Read(ctx, id)
+	switch {
+	case err == nil:
+		// all is ok
+	case errors.Is(err, apperr.ErrNotFound):
+		httputil.WriteError(w, err, http.StatusNotFound)
+	default:
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+	}
+	if err != nil {
+		return
+	}
+
+	// results of superseded grader tasks are late by definition
+	if m.HasResult() || (m.ExternalID != "" && m.ExternalID != in.TaskID.String()) {
+		httputil.WriteError(w, apperr.ErrConflict, http.StatusConflict)
+		return
+	}
+
+	m.ResultPass = in.Pass
+	m.ResultText = in.Text
+	m.ResultScore = in.Score
+	m.ResultMaxScore = in.MaxScore
+	m.ResultTests = make(model.TestResults, 0, len(in.Tests))
+	for _, t := range in.Tests {
+		m.ResultTests = append(m.ResultTests, model.TestResult{
+			Name:     t.Name,
+			Pass:     t.Pass,
+			Score:    t.Score,
+			MaxScore: t.MaxScore,
+			Message:  t.Message,
+			Package:  t.Package,
+			Status:   t.Status,
+			Elapsed:  t.Elapsed,
+			Output:   t.Output,
+		})
+	}
+	m.ResultPackages = make(model.PackageResults, 0, len(in.Packages))
+	for _, p := range in.Packages {
+		m.ResultPackages = append(m.ResultPackages, model.PackageResult{
+			Name:     p.Name,
+			Status:   p.Status,
+			Elapsed:  p.Elapsed,
+			Coverage: p.Coverage,
+			Output:   p.Output,
+		})
+	}
+
+	_, err = h.submissions.UpdateResult(ctx, m)
+	switch {
+	case err == nil:
+		// all is ok
+	case errors.Is(err, apperr.ErrConflict):
+		httputil.WriteError(w, err, http.StatusConflict)
+	case errors.Is(err, apperr.ErrNotFound):
+		httputil.WriteError(w, err, http.StatusNotFound)
+	default:
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+	}
+	if err != nil {
+		return
+	}
+
+	l.Debug().
+		Str("submission_id", m.ID.String()).
+		Bool("pass", m.ResultPass).
+		Float64("score", m.ResultScore).
+		Msg("Submission result recorded")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// subject submission of the callback token, it has to match the one from the URL if any
+func (h *ResultHandler) subject(r *http.Request) (uuid.UUID, error) {
+	tk := strings.TrimPrefix(r.Header.Get("Authorization"), bearerPrefix)
+	identity, err := h.tokens.Decode(tk)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	id, err := uuid.Parse(identity.Identity())
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("token subject: %w", token.ErrInvalidToken)
+	}
+
+	if idParam := chi.URLParam(r, "id"); idParam != "" && idParam != id.String() {
+		return uuid.Nil, fmt.Errorf("token of another submission: %w", token.ErrInvalidToken)
+	}
+
+	return id, nil
+}
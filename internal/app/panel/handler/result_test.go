@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"grader/internal/app/panel/storage"
+	"grader/internal/pkg/graderapi"
+	"grader/internal/pkg/model"
+	"grader/pkg/apperr"
+	"grader/pkg/token"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testSecretKey = "0123456789abcdef0123456789abcdef"
+
+type fakeSubmissions struct {
+	storage.SubmissionRepository
+	models  map[uuid.UUID]*model.Submission
+	reads   int
+	updated *model.Submission
+}
+
+func (f *fakeSubmissions) Read(_ context.Context, id uuid.UUID) (*model.Submission, error) {
+	f.reads++
+	if m, ok := f.models[id]; ok {
+		return m, nil
+	}
+	return nil, apperr.ErrNotFound
+}
+
+func (f *fakeSubmissions) UpdateResult(_ context.Context, m *model.Submission) (*model.Submission, error) {
+	f.updated = m
+	return m, nil
+}
+
+func TestResultHandler_Create(t *testing.T) {
+	callbacks, err := token.NewAudienceJWT(testSecretKey, "callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions, err := token.NewJWT(testSecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	taskID := uuid.New()
+	pending := &model.Submission{ID: uuid.New(), ExternalID: taskID.String()}
+	graded := &model.Submission{ID: uuid.New(), ExternalID: taskID.String(), ResultDate: time.Now()}
+	other := &model.Submission{ID: uuid.New()}
+	unknown := &model.Submission{ID: uuid.New()}
+
+	tests := []struct {
+		name       string
+		submission *model.Submission
+		tokens     token.Manager
+		tokenFor   *model.Submission
+		lifetime   time.Duration
+		// noURLID posts to the route without the submission id
+		noURLID     bool
+		wantStatus  int
+		wantUpdated bool
+	}{
+		{name: "recorded", submission: pending, tokens: callbacks, tokenFor: pending, lifetime: time.Hour, wantStatus: http.StatusNoContent, wantUpdated: true},
+		{name: "expired token", submission: pending, tokens: callbacks, tokenFor: pending, lifetime: -time.Minute, wantStatus: http.StatusUnauthorized},
+		{name: "token of another submission", submission: pending, tokens: callbacks, tokenFor: other, lifetime: time.Hour, wantStatus: http.StatusUnauthorized},
+		{name: "session token", submission: pending, tokens: sessions, tokenFor: pending, lifetime: time.Hour, wantStatus: http.StatusUnauthorized},
+		{name: "already graded", submission: graded, tokens: callbacks, tokenFor: graded, lifetime: time.Hour, wantStatus: http.StatusConflict},
+		{name: "recorded without url id", submission: pending, tokens: callbacks, tokenFor: pending, lifetime: time.Hour, noURLID: true, wantStatus: http.StatusNoContent, wantUpdated: true},
+		{name: "unknown submission", submission: unknown, tokens: callbacks, tokenFor: unknown, lifetime: time.Hour, wantStatus: http.StatusNotFound},
+		{name: "unknown submission with expired token", submission: unknown, tokens: callbacks, tokenFor: unknown, lifetime: -time.Minute, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subs := &fakeSubmissions{models: map[uuid.UUID]*model.Submission{
+				pending.ID: {ID: pending.ID, ExternalID: pending.ExternalID},
+				graded.ID:  {ID: graded.ID, ExternalID: graded.ExternalID, ResultDate: graded.ResultDate},
+				other.ID:   {ID: other.ID},
+			}}
+			h := NewResultHandler(callbacks, subs)
+
+			tk, err := tt.tokens.Issue(tt.tokenFor, tt.lifetime)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body, _ := json.Marshal(&graderapi.SubmissionResult{TaskID: taskID, Pass: true, Score: 1, MaxScore: 1})
+			r := httptest.NewRequest(http.MethodPost, "/api/submissions/result", bytes.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set("Authorization", "Bearer "+tk)
+
+			rctx := chi.NewRouteContext()
+			if !tt.noURLID {
+				rctx.URLParams.Add("id", tt.submission.ID.String())
+			}
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+			w := httptest.NewRecorder()
+			h.Create(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			// storage is not touched before the token is checked
+			if tt.wantStatus == http.StatusUnauthorized && subs.reads != 0 {
+				t.Errorf("reads = %d before the token is checked", subs.reads)
+			}
+			if (subs.updated != nil) != tt.wantUpdated {
+				t.Errorf("updated = %v, want %v", subs.updated != nil, tt.wantUpdated)
+			}
+		})
+	}
+}
@@ -1,23 +1,34 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"grader/internal/app/panel/pkg/auth"
+	"grader/internal/app/panel/pkg/grader"
 	"grader/internal/app/panel/storage"
 	"grader/internal/pkg/model"
 	"grader/pkg/apperr"
+	"grader/pkg/archive"
 	"grader/pkg/aws"
+	"grader/pkg/httpserver"
 	"grader/pkg/httputil"
 	"grader/pkg/layout"
 	"grader/pkg/logger"
 	"grader/pkg/queue"
+	"grader/pkg/token"
+	"io"
 	"mime/multipart"
 	"net/http"
+	"time"
 )
 
+// streamWriteTimeout of every write to the proxied log stream
+const streamWriteTimeout = 30 * time.Second
+
 type SubmissionHandler struct {
 	layout      *layout.Layout
 	users       storage.UserRepository
@@ -25,6 +36,10 @@ type SubmissionHandler struct {
 	submissions storage.SubmissionRepository
 	s3          *aws.S3
 	topic       queue.Topic
+	tokens      token.Manager
+
+	callbackTokenLifetime time.Duration
+	graders               *grader.Registry
 }
 
 func NewSubmitHandler(
@@ -32,6 +47,9 @@ func NewSubmitHandler(
 	s3 *aws.S3,
 	q queue.Queue,
 	topicName string,
+	tm token.Manager,
+	callbackTokenLifetime time.Duration,
+	graders *grader.Registry,
 	u storage.UserRepository,
 	a storage.AssessmentRepository,
 	s storage.SubmissionRepository,
@@ -48,6 +66,10 @@ func NewSubmitHandler(
 		submissions: s,
 		s3:          s3,
 		topic:       t,
+		tokens:      tm,
+
+		callbackTokenLifetime: callbackTokenLifetime,
+		graders:               graders,
 	}, nil
 }
 
@@ -58,6 +80,7 @@ func (h *SubmissionHandler) Create(w http.ResponseWriter, r *http.Request) {
 	user, err := auth.UserFromContext(ctx)
 	if err != nil {
 		http.Error(w, apperr.ErrForbidden.Error(), http.StatusForbidden)
+		return
 	}
 
 	idParam := chi.URLParam(r, "id")
@@ -80,70 +103,360 @@ func (h *SubmissionHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// history of an archived assessment is kept, but it takes no new submissions
+	if as.IsArchived() {
+		http.Error(w, "Assessment is archived", http.StatusGone)
+		return
+	}
+
 	if r.Method != http.MethodPost {
-		h.layout.RenderView(w, r, "template/app/views/submit/create.gohtml", nil)
+		data := map[string]interface{}{
+			"Model": as,
+		}
+		h.layout.RenderView(w, r, "template/app/views/submit/create.gohtml", data)
 		return
 	}
 
+	// every file is checked against its own limit below
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize(as.Files))
 	if err := r.ParseMultipartForm(5 * 1024 * 1025); err != nil {
 		l.Error().Err(err).Send()
-		http.Error(w, "Form parse error", http.StatusInternalServerError)
+		http.Error(w, "Form parse error", http.StatusBadRequest)
+		return
+	}
+	defer func(form *multipart.Form) {
+		_ = form.RemoveAll()
+	}(r.MultipartForm)
+
+	submissionID := uuid.New()
+
+	files := make(model.SubmissionFiles, 0, len(as.Files))
+	names := make(map[string]bool, len(as.Files))
+	for i, spec := range as.Files {
+		uploaded, err := h.uploadFile(r, user, submissionID, fileField(i), spec)
+		if err != nil {
+			if errors.Is(err, apperr.ErrInvalidInput) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			l.Error().Err(err).Send()
+			http.Error(w, "Unable to upload file", http.StatusInternalServerError)
+			return
+		}
+		for _, f := range uploaded {
+			if names[f.FileName] {
+				http.Error(w, fmt.Sprintf("%s: file %s is uploaded twice", spec.Title(), f.FileName), http.StatusBadRequest)
+				return
+			}
+			names[f.FileName] = true
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		http.Error(w, "No files uploaded", http.StatusBadRequest)
+		return
+	}
+
+	m := &model.Submission{
+		ID:           submissionID,
+		UserID:       user.ID,
+		AssessmentID: as.ID,
+		Files:        files,
+	}
+
+	if _, err := h.submissions.Create(ctx, m); err != nil {
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
 		return
 	}
-	uploadData, _, err := r.FormFile("submission_file")
+
+	// grader presents this token when reporting the result back
+	m.CallbackToken, err = h.tokens.Issue(m, h.callbackTokenLifetime)
 	if err != nil {
 		l.Error().Err(err).Send()
-		http.Error(w, "File upload error", http.StatusInternalServerError)
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.topic.Publish(m); err != nil {
+		l.Error().Err(err).Send()
+		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
 		return
 	}
+
+	http.Redirect(w, r, "/app/user/submissions", http.StatusFound)
+}
+
+// uploadFile of the form field checked against the spec, archives are unpacked,
+// nothing is returned if the optional file is missing
+func (h *SubmissionHandler) uploadFile(
+	r *http.Request,
+	user *model.User,
+	submissionID uuid.UUID,
+	field string,
+	spec model.FileSpec,
+) (model.SubmissionFiles, error) {
+	uploadData, header, err := r.FormFile(field)
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) {
+			if spec.Optional {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("%w: %s: file is required", apperr.ErrInvalidInput, spec.Title())
+		}
+		return nil, fmt.Errorf("form file: %w", err)
+	}
 	defer func(uploadData multipart.File) {
 		_ = uploadData.Close()
 	}(uploadData)
 
-	mType, err := mimetype.DetectReader(uploadData)
+	name := header.Filename
+	if err := spec.Check(name, header.Size); err != nil {
+		return nil, err
+	}
+
+	if spec.Archive {
+		return h.uploadArchive(r, user, submissionID, uploadData, header.Size, name, spec)
+	}
+
+	fileURL, err := h.putFile(r, user, submissionID, uploadData, name)
 	if err != nil {
-		l.Error().Err(err).Send()
-		http.Error(w, "Unable to detect mime type", http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 
-	submissionID := uuid.New()
+	return model.SubmissionFiles{{Label: spec.Label, FileName: name, URL: fileURL}}, nil
+}
 
-	fileName := fmt.Sprintf("%s/%s", submissionID.String(), as.FileName)
+// uploadArchive unpacked, every entry has to match the spec patterns
+func (h *SubmissionHandler) uploadArchive(
+	r *http.Request,
+	user *model.User,
+	submissionID uuid.UUID,
+	uploadData multipart.File,
+	size int64,
+	name string,
+	spec model.FileSpec,
+) (model.SubmissionFiles, error) {
+	entries, err := archive.Extract(uploadData, size, name, spec.Limits())
+	if err != nil {
+		// archive is made by the user, so any failure to unpack it is the user's one
+		return nil, fmt.Errorf("%w: %s: %v", apperr.ErrInvalidInput, spec.Title(), err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: %s: archive is empty", apperr.ErrInvalidInput, spec.Title())
+	}
+	for _, e := range entries {
+		if err := spec.CheckEntry(e.Path); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := h.s3.Put(uploadData, fileName, mType.String(), user.ID.String()); err != nil {
-		l.Error().Err(err).Send()
-		http.Error(w, "Unable to upload file", http.StatusInternalServerError)
-		return
+	files := make(model.SubmissionFiles, 0, len(entries))
+	for _, e := range entries {
+		fileURL, err := h.putFile(r, user, submissionID, bytes.NewReader(e.Content), e.Path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, model.SubmissionFile{Label: spec.Label, FileName: e.Path, URL: fileURL})
 	}
 
-	fileURL, err := h.s3.GetLink(fileName)
+	return files, nil
+}
+
+// putFile to the submission dir of the storage, the download link is returned
+func (h *SubmissionHandler) putFile(
+	r *http.Request,
+	user *model.User,
+	submissionID uuid.UUID,
+	data io.ReadSeeker,
+	name string,
+) (string, error) {
+	l := logger.Ctx(r.Context())
+
+	mType, err := mimetype.DetectReader(data)
 	if err != nil {
-		l.Error().Err(err).Send()
-		http.Error(w, "Unable to upload file", http.StatusInternalServerError)
-		return
+		return "", fmt.Errorf("mime type: %w", err)
+	}
+	// detection consumes the beginning of the file
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("seek: %w", err)
+	}
+
+	objectName := fmt.Sprintf("%s/%s", submissionID.String(), name)
+
+	if err := h.s3.Put(data, objectName, mType.String(), user.ID.String()); err != nil {
+		return "", fmt.Errorf("s3 put: %w", err)
+	}
+
+	fileURL, err := h.s3.GetLink(objectName)
+	if err != nil {
+		return "", fmt.Errorf("s3 link: %w", err)
 	}
 
 	l.Debug().Str("download-url", fileURL).Msg("Got download link")
 
-	m := &model.Submission{
-		UserID:       user.ID,
-		AssessmentID: as.ID,
-		FileName:     as.FileName,
-		FileURL:      fileURL,
+	return fileURL, nil
+}
+
+// fileField of the upload form for the i-th file spec
+func fileField(i int) string {
+	return fmt.Sprintf("file_%d", i)
+}
+
+// maxUploadSize of the form with all the files, multipart overhead included
+func maxUploadSize(specs model.FileSpecs) int64 {
+	size := int64(1 << 20)
+	for _, s := range specs {
+		size += s.MaxFileSize()
 	}
+	return size
+}
 
-	if _, err := h.submissions.Create(ctx, m); err != nil {
+// List submissions of the current user
+func (h *SubmissionHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	user, err := auth.UserFromContext(ctx)
+	if err != nil {
+		http.Error(w, apperr.ErrForbidden.Error(), http.StatusForbidden)
+		return
+	}
+
+	models, err := h.submissions.AllByUserID(ctx, user.ID)
+	if err != nil {
 		l.Error().Err(err).Send()
 		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
 		return
 	}
 
-	if err := h.topic.Publish(m); err != nil {
+	data := map[string]interface{}{
+		"Models": models,
+	}
+
+	h.layout.RenderView(w, r, "template/app/views/submit/list.gohtml", data)
+}
+
+// Logs page following output of the submission grading
+func (h *SubmissionHandler) Logs(w http.ResponseWriter, r *http.Request) {
+	m, status := h.ownSubmission(r)
+	if m == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Model": m,
+	}
+
+	h.layout.RenderView(w, r, "template/app/views/submit/logs.gohtml", data)
+}
+
+// LogsStream proxies the grader log event stream of the submission task
+func (h *SubmissionHandler) LogsStream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	m, status := h.ownSubmission(r)
+	if m == nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if m.ExternalID == "" {
+		http.Error(w, "Submission is not sent to the grader yet", http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, apperr.ErrInternal.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	as, err := h.assessments.Read(ctx, m.AssessmentID)
+	if err != nil {
 		l.Error().Err(err).Send()
-		httputil.WriteError(w, apperr.ErrInternal, http.StatusInternalServerError)
+		http.Error(w, apperr.ErrInternal.Error(), http.StatusInternalServerError)
+		return
+	}
+	gc, err := h.graders.Client(ctx, as.ExternalGrader)
+	if err != nil {
+		l.Error().Err(err).Send()
+		http.Error(w, "Grader is unavailable", http.StatusBadGateway)
 		return
 	}
 
-	http.Redirect(w, r, "/app/user/submissions", http.StatusFound)
+	resp, err := gc.Logs(ctx, m.ExternalID)
+	if err != nil {
+		l.Error().Err(err).Msg("Unable to connect to the grader")
+		http.Error(w, "Grader is unavailable", http.StatusBadGateway)
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		// the task is expired or the grader was restarted
+		http.Error(w, "Logs are not available", http.StatusNotFound)
+		return
+	}
+
+	// the stream outlives the server write timeout, but a client not reading it is still cut off
+	_ = httpserver.ExtendWriteDeadline(r, streamWriteTimeout)
+
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	buf := make([]byte, 4*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			_ = httpserver.ExtendWriteDeadline(r, streamWriteTimeout)
+			if _, err := w.Write(buf[:n]); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+		if err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				l.Error().Err(err).Msg("Log stream interrupted")
+			}
+			return
+		}
+	}
+}
+
+// ownSubmission from the URL, visible to its author and admins only
+func (h *SubmissionHandler) ownSubmission(r *http.Request) (*model.Submission, int) {
+	ctx := r.Context()
+	l := logger.Ctx(ctx)
+
+	user, err := auth.UserFromContext(ctx)
+	if err != nil {
+		return nil, http.StatusForbidden
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return nil, http.StatusNotFound
+	}
+
+	m, err := h.submissions.Read(ctx, id)
+	if err != nil {
+		if !errors.Is(err, apperr.ErrNotFound) {
+			l.Error().Err(err).Send()
+			return nil, http.StatusInternalServerError
+		}
+		return nil, http.StatusNotFound
+	}
+
+	if m.UserID != user.ID && !user.IsAdmin {
+		return nil, http.StatusNotFound
+	}
+
+	return m, http.StatusOK
 }
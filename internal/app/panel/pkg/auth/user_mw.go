@@ -71,3 +71,23 @@ func AuthMiddleware() func(next http.Handler) http.Handler {
 		})
 	}
 }
+
+// AdminMiddleware refuses the users without the admin role, it is applied after AuthMiddleware
+func AdminMiddleware() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, err := UserFromContext(r.Context())
+			if err != nil {
+				http.Error(w, "No session", http.StatusUnauthorized)
+				return
+			}
+
+			if !user.IsAdmin {
+				http.Error(w, "Admin role is required", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
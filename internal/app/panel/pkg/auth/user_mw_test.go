@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"grader/internal/pkg/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminMiddleware(t *testing.T) {
+	tests := []struct {
+		name string
+		user *model.User
+		want int
+	}{
+		{name: "anonymous", user: nil, want: http.StatusUnauthorized},
+		{name: "student", user: &model.User{Name: "student"}, want: http.StatusForbidden},
+		{name: "admin", user: &model.User{Name: "admin", IsAdmin: true}, want: http.StatusOK},
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	h := AuthMiddleware()(AdminMiddleware()(next))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/app/admin/graders/create", nil)
+			if tt.user != nil {
+				r = r.WithContext(context.WithValue(r.Context(), contextKeyUser{}, tt.user))
+			}
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package grader
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"grader/internal/pkg/graderapi"
+	"grader/internal/pkg/model"
+	"grader/pkg/apperr"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// resolveTimeout covers the image pull done by the grader
+const resolveTimeout = 5 * time.Minute
+
+// prewarmTimeout covers the pulls of all the images
+const prewarmTimeout = 15 * time.Minute
+
+// Client of the grader API
+type Client struct {
+	client *resty.Client
+	url    string
+}
+
+// NewClient of the grader at the url, the secret is presented as the bearer token unless empty
+func NewClient(url string, secret string) *Client {
+	c := &Client{
+		client: resty.New(),
+		url:    strings.TrimRight(url, "/"),
+	}
+	if secret != "" {
+		c.client.SetAuthToken(secret)
+	}
+
+	return c
+}
+
+// ResolveImage to its digest, images rejected by the grader policy are reported as apperr.ErrInvalidInput
+func (c *Client) ResolveImage(ctx context.Context, image string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	out := &graderapi.ResolveImageResponse{}
+
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(&graderapi.ResolveImageRequest{Image: image}).
+		SetResult(out).
+		Post(c.url + "/images/resolve")
+	if err != nil {
+		return "", fmt.Errorf("grader request: %w", err)
+	}
+
+	switch code := resp.StatusCode(); {
+	case code == http.StatusBadRequest:
+		return "", fmt.Errorf("%w: %s", apperr.ErrInvalidInput, resp.String())
+	case code != http.StatusOK:
+		return "", fmt.Errorf("grader response: %s: %s", resp.Status(), resp.String())
+	}
+
+	return out.Digest, nil
+}
+
+// PrewarmImages on the grader host
+func (c *Client) PrewarmImages(ctx context.Context, images []string) ([]graderapi.PrewarmResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, prewarmTimeout)
+	defer cancel()
+
+	out := &graderapi.PrewarmImagesResponse{}
+
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(&graderapi.PrewarmImagesRequest{Images: images}).
+		SetResult(out).
+		Post(c.url + "/images/prewarm")
+	if err != nil {
+		return nil, fmt.Errorf("grader request: %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("grader response: %s: %s", resp.Status(), resp.String())
+	}
+
+	return out.Images, nil
+}
+
+// Logs event stream of the task, the caller closes the response body
+func (c *Client) Logs(ctx context.Context, taskID string) (*http.Response, error) {
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetHeader("Accept", "text/event-stream").
+		SetDoNotParseResponse(true).
+		Get(c.url + "/submissions/" + url.PathEscape(taskID) + "/logs")
+	if err != nil {
+		return nil, fmt.Errorf("grader request: %w", err)
+	}
+
+	return resp.RawResponse, nil
+}
+
+// AssessmentImages pinned by the assessments, without duplicates, archived assessments are skipped
+func AssessmentImages(models []*model.Assessment) []string {
+	seen := make(map[string]bool, len(models))
+	images := make([]string, 0, len(models))
+	for _, m := range models {
+		image := m.PinnedImage()
+		if image == "" || seen[image] || m.IsArchived() {
+			continue
+		}
+		seen[image] = true
+		images = append(images, image)
+	}
+
+	return images
+}
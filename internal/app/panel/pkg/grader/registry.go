@@ -0,0 +1,100 @@
+package grader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"grader/internal/app/panel/storage"
+	"grader/internal/pkg/graderapi"
+	"grader/internal/pkg/model"
+	"grader/pkg/apperr"
+)
+
+var ErrNotRegistered = fmt.Errorf("grader is not registered: %w", apperr.ErrInvalidInput)
+
+// Registry of the graders, assessments without the external grader are served by the default one
+type Registry struct {
+	def     *Client
+	graders storage.GraderRepository
+}
+
+func NewRegistry(def *Client, graders storage.GraderRepository) *Registry {
+	return &Registry{def: def, graders: graders}
+}
+
+// Client of the named grader, the default one if the name is empty
+func (r *Registry) Client(ctx context.Context, name string) (*Client, error) {
+	if name == "" {
+		return r.def, nil
+	}
+
+	g, err := r.graders.ReadByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, fmt.Errorf("%s: %w", name, ErrNotRegistered)
+		}
+		return nil, fmt.Errorf("grader %s: %w", name, err)
+	}
+
+	return NewClient(g.URL, g.Secret), nil
+}
+
+// PrewarmResult of the image on the grader
+type PrewarmResult struct {
+	// Grader name, empty for the default one
+	Grader string
+	graderapi.PrewarmResult
+}
+
+// Prewarm the images of the assessments on the graders they are assigned to,
+// failures of a grader, e.g. a disabled one, are reported as the results of its images
+func (r *Registry) Prewarm(ctx context.Context, models []*model.Assessment) []PrewarmResult {
+	var names []string
+	byGrader := make(map[string][]*model.Assessment)
+	for _, m := range models {
+		if _, ok := byGrader[m.ExternalGrader]; !ok {
+			names = append(names, m.ExternalGrader)
+		}
+		byGrader[m.ExternalGrader] = append(byGrader[m.ExternalGrader], m)
+	}
+
+	var out []PrewarmResult
+	for _, name := range names {
+		images := AssessmentImages(byGrader[name])
+		if len(images) == 0 {
+			continue
+		}
+
+		results, err := r.prewarm(ctx, name, images)
+		if err != nil {
+			// the other graders are still worth warming up
+			results = make([]graderapi.PrewarmResult, 0, len(images))
+			for _, image := range images {
+				results = append(results, graderapi.PrewarmResult{Image: image, Error: err.Error()})
+			}
+		}
+		for _, res := range results {
+			out = append(out, PrewarmResult{Grader: name, PrewarmResult: res})
+		}
+	}
+
+	return out
+}
+
+func (r *Registry) prewarm(ctx context.Context, name string, images []string) ([]graderapi.PrewarmResult, error) {
+	c := r.def
+	if name != "" {
+		g, err := r.graders.ReadByName(ctx, name)
+		switch {
+		case errors.Is(err, apperr.ErrNotFound):
+			return nil, fmt.Errorf("%s: %w", name, ErrNotRegistered)
+		case err != nil:
+			return nil, fmt.Errorf("grader %s: %w", name, err)
+		case !g.Enabled:
+			return nil, fmt.Errorf("grader %s is disabled", name)
+		}
+		c = NewClient(g.URL, g.Secret)
+	}
+
+	return c.PrewarmImages(ctx, images)
+}
@@ -23,6 +23,13 @@ type AssessmentRepository interface {
 	All(ctx context.Context) ([]*model.Assessment, error)
 	// Read instance of model.Assessment
 	Read(ctx context.Context, id uuid.UUID) (*model.Assessment, error)
+	// Update model.Assessment
+	Update(ctx context.Context, m *model.Assessment) (*model.Assessment, error)
+	// SetArchived state of model.Assessment, archived ones do not accept submissions
+	SetArchived(ctx context.Context, id uuid.UUID, archived bool) error
+	// Delete model.Assessment with its submissions, it is refused with apperr.ErrConflict unless their count
+	// is the confirmed one
+	Delete(ctx context.Context, id uuid.UUID, confirmedSubmissions int) error
 }
 
 type SubmissionRepository interface {
@@ -34,4 +41,21 @@ type SubmissionRepository interface {
 	AllByUserID(ctx context.Context, userID uuid.UUID) ([]*model.Submission, error)
 	// Read instance of model.Submission
 	Read(ctx context.Context, id uuid.UUID) (*model.Submission, error)
+	// CountByAssessmentID of model.Submission
+	CountByAssessmentID(ctx context.Context, assessmentID uuid.UUID) (int, error)
+	// UpdateResult of model.Submission unless it is already finalized
+	UpdateResult(ctx context.Context, m *model.Submission) (*model.Submission, error)
+}
+
+type GraderRepository interface {
+	// Create a new model.Grader
+	Create(ctx context.Context, m *model.Grader) (*model.Grader, error)
+	// All instances of model.Grader
+	All(ctx context.Context) ([]*model.Grader, error)
+	// Read instance of model.Grader
+	Read(ctx context.Context, id uuid.UUID) (*model.Grader, error)
+	// ReadByName instance of model.Grader
+	ReadByName(ctx context.Context, name string) (*model.Grader, error)
+	// Update settings of model.Grader, the name is kept
+	Update(ctx context.Context, m *model.Grader) (*model.Grader, error)
 }
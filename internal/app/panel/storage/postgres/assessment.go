@@ -32,8 +32,8 @@ func NewAssessmentRepository(db *sql.DB) (*AssessmentRepository, error) {
 // Create implementation of interface storage.AssessmentRepository
 func (r *AssessmentRepository) Create(ctx context.Context, m *model.Assessment) (*model.Assessment, error) {
 	const SQL = `
-		INSERT INTO assessments (part_id, container_image, summary, file_name)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO assessments (part_id, container_image, container_image_digest, summary, files, sandbox, result_mode, test_cases, external_grader)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id
 `
 
@@ -42,8 +42,13 @@ func (r *AssessmentRepository) Create(ctx context.Context, m *model.Assessment)
 		SQL,
 		m.PartID,
 		m.ContainerImage,
+		m.ContainerImageDigest,
 		m.Summary,
-		m.FileName,
+		m.Files,
+		m.Sandbox,
+		m.ResultMode,
+		m.TestCases,
+		m.ExternalGrader,
 	).Scan(&m.ID)
 	if err != nil {
 		if pgErr, ok := err.(*pg.Error); ok {
@@ -61,20 +66,11 @@ func (r *AssessmentRepository) Create(ctx context.Context, m *model.Assessment)
 // Read implementation of interface storage.AssessmentRepository
 func (r *AssessmentRepository) Read(ctx context.Context, id uuid.UUID) (*model.Assessment, error) {
 	const SQL = `
-		SELECT id, created_at, part_id, container_image, summary, file_name
+		SELECT id, created_at, part_id, container_image, container_image_digest, summary, files, sandbox, result_mode, test_cases, external_grader, archived_at
 		FROM assessments 
 		WHERE id=$1
 `
-	m := &model.Assessment{}
-
-	err := r.db.QueryRowContext(ctx, SQL, id).Scan(
-		&m.ID,
-		&m.CreatedAt,
-		&m.PartID,
-		&m.ContainerImage,
-		&m.Summary,
-		&m.FileName,
-	)
+	m, err := scanAssessment(r.db.QueryRowContext(ctx, SQL, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, apperr.ErrNotFound
@@ -89,7 +85,7 @@ func (r *AssessmentRepository) All(ctx context.Context) ([]*model.Assessment, er
 	l := logger.Ctx(ctx).With().Str("method", "All").Logger()
 
 	const SQL = `
-		SELECT id, created_at, part_id, container_image, summary, file_name
+		SELECT id, created_at, part_id, container_image, container_image_digest, summary, files, sandbox, result_mode, test_cases, external_grader, archived_at
 		FROM assessments
 		ORDER BY created_at
 `
@@ -112,15 +108,8 @@ func (r *AssessmentRepository) All(ctx context.Context) ([]*model.Assessment, er
 			l.Debug().Err(err).Send()
 			return nil, fmt.Errorf("rows next: %w", err)
 		}
-		m := &model.Assessment{}
-		if err := rows.Scan(
-			&m.ID,
-			&m.CreatedAt,
-			&m.PartID,
-			&m.ContainerImage,
-			&m.Summary,
-			&m.FileName,
-		); err != nil {
+		m, err := scanAssessment(rows)
+		if err != nil {
 			l.Debug().Err(err).Send()
 			return nil, fmt.Errorf("scan: %w", err)
 		}
@@ -131,3 +120,167 @@ func (r *AssessmentRepository) All(ctx context.Context) ([]*model.Assessment, er
 
 	return res, nil
 }
+
+// Update implementation of interface storage.AssessmentRepository
+func (r *AssessmentRepository) Update(ctx context.Context, m *model.Assessment) (*model.Assessment, error) {
+	const SQL = `
+		UPDATE assessments
+		SET part_id=$2,
+			container_image=$3,
+			container_image_digest=$4,
+			summary=$5,
+			files=$6,
+			sandbox=$7,
+			result_mode=$8,
+			test_cases=$9,
+			external_grader=$10
+		WHERE id=$1
+`
+	res, err := r.db.ExecContext(
+		ctx,
+		SQL,
+		m.ID,
+		m.PartID,
+		m.ContainerImage,
+		m.ContainerImageDigest,
+		m.Summary,
+		m.Files,
+		m.Sandbox,
+		m.ResultMode,
+		m.TestCases,
+		m.ExternalGrader,
+	)
+	if err != nil {
+		if pgErr, ok := err.(*pg.Error); ok {
+			if pgerrcode.IsIntegrityConstraintViolation(string(pgErr.Code)) {
+				return nil, apperr.ErrConflict
+			}
+		}
+
+		return nil, fmt.Errorf("update: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return nil, apperr.ErrNotFound
+	}
+
+	return m, nil
+}
+
+// SetArchived implementation of interface storage.AssessmentRepository
+func (r *AssessmentRepository) SetArchived(ctx context.Context, id uuid.UUID, archived bool) error {
+	const SQL = `
+		UPDATE assessments
+		SET archived_at=CASE WHEN $2 THEN COALESCE(archived_at, NOW()) END
+		WHERE id=$1
+`
+	res, err := r.db.ExecContext(ctx, SQL, id, archived)
+	if err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return apperr.ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete implementation of interface storage.AssessmentRepository
+func (r *AssessmentRepository) Delete(ctx context.Context, id uuid.UUID, confirmedSubmissions int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// new submissions of the assessment wait for the lock, so none of them is missed
+	const lockSQL = `
+		SELECT id
+		FROM assessments
+		WHERE id=$1
+		FOR UPDATE
+`
+	if err := tx.QueryRowContext(ctx, lockSQL, id).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.ErrNotFound
+		}
+		return fmt.Errorf("lock: %w", err)
+	}
+
+	const countSQL = `
+		SELECT COUNT(*)
+		FROM submissions
+		WHERE assessment_id=$1
+`
+	var count int
+	if err := tx.QueryRowContext(ctx, countSQL, id).Scan(&count); err != nil {
+		return fmt.Errorf("count submissions: %w", err)
+	}
+	// the confirmation is given for the count shown, the submissions made since are not deleted unseen
+	if count != confirmedSubmissions {
+		return fmt.Errorf("assessment has %d submissions, %d confirmed: %w", count, confirmedSubmissions, apperr.ErrConflict)
+	}
+
+	if count > 0 {
+		const submissionsSQL = `
+		DELETE FROM submissions
+		WHERE assessment_id=$1
+`
+		if _, err := tx.ExecContext(ctx, submissionsSQL, id); err != nil {
+			return fmt.Errorf("delete submissions: %w", err)
+		}
+	}
+
+	const SQL = `
+		DELETE FROM assessments
+		WHERE id=$1
+`
+	if _, err := tx.ExecContext(ctx, SQL, id); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
+
+// scanAssessment row into model.Assessment, it is active until archived
+func scanAssessment(row scanner) (*model.Assessment, error) {
+	m := &model.Assessment{}
+
+	var archivedAt sql.NullTime
+
+	if err := row.Scan(
+		&m.ID,
+		&m.CreatedAt,
+		&m.PartID,
+		&m.ContainerImage,
+		&m.ContainerImageDigest,
+		&m.Summary,
+		&m.Files,
+		&m.Sandbox,
+		&m.ResultMode,
+		&m.TestCases,
+		&m.ExternalGrader,
+		&archivedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	m.ArchivedAt = archivedAt.Time
+
+	return m, nil
+}
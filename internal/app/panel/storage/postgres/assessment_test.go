@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"grader/pkg/apperr"
+	"testing"
+)
+
+func TestAssessmentRepository_Delete(t *testing.T) {
+	tests := []struct {
+		name        string
+		submissions int
+		confirmed   int
+		missing     bool
+		wantErr     error
+	}{
+		{name: "no submissions"},
+		{name: "submissions", submissions: 2, wantErr: apperr.ErrConflict},
+		{name: "confirmed", submissions: 2, confirmed: 2},
+		{name: "more than confirmed", submissions: 3, confirmed: 2, wantErr: apperr.ErrConflict},
+		{name: "missing", missing: true, wantErr: apperr.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mdb, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer func() {
+				_ = mdb.Close()
+			}()
+
+			id := uuid.New()
+
+			mock.ExpectBegin()
+			lock := mock.ExpectQuery(`SELECT id FROM assessments (.+) FOR UPDATE`).WithArgs(id)
+			switch {
+			case tt.missing:
+				lock.WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			default:
+				lock.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM submissions`).WithArgs(id).WillReturnRows(
+					sqlmock.NewRows([]string{"count"}).AddRow(tt.submissions),
+				)
+			}
+			switch {
+			case tt.missing:
+			case tt.submissions != tt.confirmed:
+				mock.ExpectRollback()
+			default:
+				if tt.submissions > 0 {
+					mock.ExpectExec(`DELETE FROM submissions`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, int64(tt.submissions)))
+				}
+				mock.ExpectExec(`DELETE FROM assessments`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			r, _ := NewAssessmentRepository(mdb)
+
+			if err := r.Delete(context.Background(), id, tt.confirmed); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	pg "github.com/lib/pq"
+	"grader/internal/app/panel/storage"
+	"grader/internal/pkg/model"
+	"grader/pkg/apperr"
+)
+
+// storage.GraderRepository interface implementation
+var _ storage.GraderRepository = (*GraderRepository)(nil)
+
+type GraderRepository struct {
+	db *sql.DB
+}
+
+func NewGraderRepository(db *sql.DB) (*GraderRepository, error) {
+	s := &GraderRepository{
+		db: db,
+	}
+
+	return s, nil
+}
+
+// Create implementation of interface storage.GraderRepository
+func (r *GraderRepository) Create(ctx context.Context, m *model.Grader) (*model.Grader, error) {
+	const SQL = `
+		INSERT INTO graders (name, url, secret, enabled, max_concurrency)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+`
+
+	err := r.db.QueryRowContext(
+		ctx,
+		SQL,
+		m.Name,
+		m.URL,
+		m.Secret,
+		m.Enabled,
+		m.MaxConcurrency,
+	).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		if pgErr, ok := err.(*pg.Error); ok {
+			if pgerrcode.IsIntegrityConstraintViolation(string(pgErr.Code)) {
+				return nil, apperr.ErrConflict
+			}
+		}
+
+		return nil, fmt.Errorf("insert: %w", err)
+	}
+
+	return m, nil
+}
+
+// All implementation of interface storage.GraderRepository
+func (r *GraderRepository) All(ctx context.Context) ([]*model.Grader, error) {
+	const SQL = `
+		SELECT id, created_at, name, url, secret, enabled, max_concurrency
+		FROM graders
+		ORDER BY name
+`
+	rows, err := r.db.QueryContext(ctx, SQL)
+	if err != nil {
+		return nil, fmt.Errorf("select: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	res := make([]*model.Grader, 0)
+	for rows.Next() {
+		m := &model.Grader{}
+		if err := scanGrader(rows, m); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+
+	return res, nil
+}
+
+// Read implementation of interface storage.GraderRepository
+func (r *GraderRepository) Read(ctx context.Context, id uuid.UUID) (*model.Grader, error) {
+	const SQL = `
+		SELECT id, created_at, name, url, secret, enabled, max_concurrency
+		FROM graders
+		WHERE id=$1
+`
+	return r.read(ctx, SQL, id)
+}
+
+// ReadByName implementation of interface storage.GraderRepository
+func (r *GraderRepository) ReadByName(ctx context.Context, name string) (*model.Grader, error) {
+	const SQL = `
+		SELECT id, created_at, name, url, secret, enabled, max_concurrency
+		FROM graders
+		WHERE name=$1
+`
+	return r.read(ctx, SQL, name)
+}
+
+func (r *GraderRepository) read(ctx context.Context, query string, arg interface{}) (*model.Grader, error) {
+	m := &model.Grader{}
+
+	if err := scanGrader(r.db.QueryRowContext(ctx, query, arg), m); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.ErrNotFound
+		}
+		return nil, fmt.Errorf("select: %w", err)
+	}
+
+	return m, nil
+}
+
+// Update implementation of interface storage.GraderRepository
+func (r *GraderRepository) Update(ctx context.Context, m *model.Grader) (*model.Grader, error) {
+	const SQL = `
+		UPDATE graders
+		SET url=$2, secret=$3, enabled=$4, max_concurrency=$5
+		WHERE id=$1
+`
+	res, err := r.db.ExecContext(ctx, SQL, m.ID, m.URL, m.Secret, m.Enabled, m.MaxConcurrency)
+	if err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return nil, apperr.ErrNotFound
+	}
+
+	return m, nil
+}
+
+func scanGrader(row interface{ Scan(...interface{}) error }, m *model.Grader) error {
+	return row.Scan(
+		&m.ID,
+		&m.CreatedAt,
+		&m.Name,
+		&m.URL,
+		&m.Secret,
+		&m.Enabled,
+		&m.MaxConcurrency,
+	)
+}
@@ -33,22 +33,27 @@ func NewSubmissionRepository(db *sql.DB) (*SubmissionRepository, error) {
 func (r *SubmissionRepository) Create(ctx context.Context, m *model.Submission) (*model.Submission, error) {
 	const SQL = `
 		INSERT INTO Submissions (
+			id,
 			user_id,
 			assessment_id,
-			file_name,
-			file_url
+			files
 		)
 		VALUES ($1, $2, $3, $4)
-		RETURNING id
 `
-	err := r.db.QueryRowContext(
+	// the files are uploaded under the submission ID before it is stored
+	id := m.ID
+	if id == uuid.Nil {
+		id = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(
 		ctx,
 		SQL,
+		id,
 		m.UserID,
 		m.AssessmentID,
-		m.FileName,
-		m.FileURL,
-	).Scan(&m.ID)
+		m.Files,
+	)
 	if err != nil {
 		if pgErr, ok := err.(*pg.Error); ok {
 			if pgerrcode.IsIntegrityConstraintViolation(string(pgErr.Code)) {
@@ -58,6 +63,7 @@ func (r *SubmissionRepository) Create(ctx context.Context, m *model.Submission)
 
 		return nil, fmt.Errorf("insert: %w", err)
 	}
+	m.ID = id
 
 	return m, nil
 }
@@ -70,29 +76,19 @@ func (r *SubmissionRepository) Read(ctx context.Context, id uuid.UUID) (*model.S
 			created_at,
 			user_id,
 			assessment_id,
-			file_name,
-			file_url,
+			files,
 			external_id,
 			result_date,
 			result_pass,
-			result_text
+			result_text,
+			result_score,
+			result_max_score,
+			result_tests,
+			result_packages
 		FROM Submissions 
 		WHERE id=$1
 `
-	m := &model.Submission{}
-
-	err := r.db.QueryRowContext(ctx, SQL, id).Scan(
-		&m.ID,
-		&m.CreatedAt,
-		m.UserID,
-		m.AssessmentID,
-		m.FileName,
-		m.FileURL,
-		m.ExternalID,
-		m.ResultDate,
-		m.ResultPass,
-		m.ResultText,
-	)
+	m, err := scanSubmission(r.db.QueryRowContext(ctx, SQL, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, apperr.ErrNotFound
@@ -103,6 +99,60 @@ func (r *SubmissionRepository) Read(ctx context.Context, id uuid.UUID) (*model.S
 	return m, nil
 }
 
+// CountByAssessmentID implementation of interface storage.SubmissionRepository
+func (r *SubmissionRepository) CountByAssessmentID(ctx context.Context, assessmentID uuid.UUID) (int, error) {
+	const SQL = `
+		SELECT COUNT(*)
+		FROM submissions
+		WHERE assessment_id=$1
+`
+	var count int
+	if err := r.db.QueryRowContext(ctx, SQL, assessmentID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("select: %w", err)
+	}
+
+	return count, nil
+}
+
+// UpdateResult implementation of interface storage.SubmissionRepository
+func (r *SubmissionRepository) UpdateResult(ctx context.Context, m *model.Submission) (*model.Submission, error) {
+	const SQL = `
+		UPDATE Submissions
+		SET result_date=NOW(),
+			result_pass=$2,
+			result_text=$3,
+			result_score=$4,
+			result_max_score=$5,
+			result_tests=$6,
+			result_packages=$7
+		WHERE id=$1 AND result_date IS NULL
+		RETURNING result_date
+`
+	err := r.db.QueryRowContext(
+		ctx,
+		SQL,
+		m.ID,
+		m.ResultPass,
+		m.ResultText,
+		m.ResultScore,
+		m.ResultMaxScore,
+		m.ResultTests,
+		m.ResultPackages,
+	).Scan(&m.ResultDate)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("update: %w", err)
+		}
+		// tell a missing submission apart from an already finalized one
+		if _, err := r.Read(ctx, m.ID); err != nil {
+			return nil, err
+		}
+		return nil, apperr.ErrConflict
+	}
+
+	return m, nil
+}
+
 func (r *SubmissionRepository) All(ctx context.Context) ([]*model.Submission, error) {
 	l := logger.Ctx(ctx).With().Str("method", "All").Logger()
 
@@ -112,12 +162,15 @@ func (r *SubmissionRepository) All(ctx context.Context) ([]*model.Submission, er
 			created_at,
 			user_id,
 			assessment_id,
-			file_name,
-			file_url,
+			files,
 			external_id,
 			result_date,
 			result_pass,
-			result_text
+			result_text,
+			result_score,
+			result_max_score,
+			result_tests,
+			result_packages
 		FROM Submissions
 		ORDER BY created_at
 `
@@ -140,19 +193,8 @@ func (r *SubmissionRepository) All(ctx context.Context) ([]*model.Submission, er
 			l.Debug().Err(err).Send()
 			return nil, fmt.Errorf("rows next: %w", err)
 		}
-		m := &model.Submission{}
-		if err := rows.Scan(
-			&m.ID,
-			&m.CreatedAt,
-			m.UserID,
-			m.AssessmentID,
-			m.FileName,
-			m.FileURL,
-			m.ExternalID,
-			m.ResultDate,
-			m.ResultPass,
-			m.ResultText,
-		); err != nil {
+		m, err := scanSubmission(rows)
+		if err != nil {
 			l.Debug().Err(err).Send()
 			return nil, fmt.Errorf("scan: %w", err)
 		}
@@ -173,12 +215,15 @@ func (r *SubmissionRepository) AllByUserID(ctx context.Context, userID uuid.UUID
 			created_at,
 			user_id,
 			assessment_id,
-			file_name,
-			file_url,
+			files,
 			external_id,
 			result_date,
 			result_pass,
-			result_text
+			result_text,
+			result_score,
+			result_max_score,
+			result_tests,
+			result_packages
 		FROM Submissions
 		WHERE user_id=$1
 		ORDER BY created_at
@@ -202,19 +247,8 @@ func (r *SubmissionRepository) AllByUserID(ctx context.Context, userID uuid.UUID
 			l.Debug().Err(err).Send()
 			return nil, fmt.Errorf("rows next: %w", err)
 		}
-		m := &model.Submission{}
-		if err := rows.Scan(
-			&m.ID,
-			&m.CreatedAt,
-			m.UserID,
-			m.AssessmentID,
-			m.FileName,
-			m.FileURL,
-			m.ExternalID,
-			m.ResultDate,
-			m.ResultPass,
-			m.ResultText,
-		); err != nil {
+		m, err := scanSubmission(rows)
+		if err != nil {
 			l.Debug().Err(err).Send()
 			return nil, fmt.Errorf("scan: %w", err)
 		}
@@ -225,3 +259,48 @@ func (r *SubmissionRepository) AllByUserID(ctx context.Context, userID uuid.UUID
 
 	return res, nil
 }
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSubmission row into model.Submission, result columns are empty until the grader reports back
+func scanSubmission(row scanner) (*model.Submission, error) {
+	m := &model.Submission{}
+
+	var (
+		externalID sql.NullString
+		resultDate sql.NullTime
+		resultPass sql.NullBool
+		resultText sql.NullString
+		score      sql.NullFloat64
+		maxScore   sql.NullFloat64
+	)
+
+	if err := row.Scan(
+		&m.ID,
+		&m.CreatedAt,
+		&m.UserID,
+		&m.AssessmentID,
+		&m.Files,
+		&externalID,
+		&resultDate,
+		&resultPass,
+		&resultText,
+		&score,
+		&maxScore,
+		&m.ResultTests,
+		&m.ResultPackages,
+	); err != nil {
+		return nil, err
+	}
+
+	m.ExternalID = externalID.String
+	m.ResultDate = resultDate.Time
+	m.ResultPass = resultPass.Bool
+	m.ResultText = resultText.String
+	m.ResultScore = score.Float64
+	m.ResultMaxScore = maxScore.Float64
+
+	return m, nil
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"grader/internal/pkg/model"
+	"grader/pkg/apperr"
+	"testing"
+	"time"
+)
+
+func TestSubmissionRepository_Create(t *testing.T) {
+	mdb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer func() {
+		_ = mdb.Close()
+	}()
+
+	uploadedUUID := uuid.New()
+	userUUID := uuid.New()
+	assessmentUUID := uuid.New()
+
+	mock.ExpectExec(`INSERT INTO Submissions`).WithArgs(uploadedUUID, userUUID, assessmentUUID, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO Submissions`).WithArgs(sqlmock.AnyArg(), userUUID, assessmentUUID, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	r := &SubmissionRepository{db: mdb}
+
+	got, err := r.Create(context.TODO(), &model.Submission{ID: uploadedUUID, UserID: userUUID, AssessmentID: assessmentUUID})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if got.ID != uploadedUUID {
+		t.Errorf("Create() id = %v, want %v", got.ID, uploadedUUID)
+	}
+
+	got, err = r.Create(context.TODO(), &model.Submission{UserID: userUUID, AssessmentID: assessmentUUID})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if got.ID == uuid.Nil {
+		t.Error("Create() id is not generated")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSubmissionRepository_UpdateResult(t *testing.T) {
+	mdb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	pendingUUID := uuid.New()
+	finalizedUUID := uuid.New()
+	missingUUID := uuid.New()
+	resultDate := time.Now()
+
+	mock.ExpectQuery(`UPDATE Submissions`).WithArgs(pendingUUID, true, "OK", 1.0, 1.0, sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnRows(
+		sqlmock.NewRows([]string{"result_date"}).AddRow(resultDate),
+	)
+	mock.ExpectQuery(`UPDATE Submissions`).WithArgs(finalizedUUID, false, "FAIL", 0.0, 1.0, sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT (.+) FROM Submissions`).WithArgs(finalizedUUID).WillReturnRows(
+		sqlmock.NewRows([]string{
+			"id", "created_at", "user_id", "assessment_id", "files",
+			"external_id", "result_date", "result_pass", "result_text",
+			"result_score", "result_max_score", "result_tests", "result_packages",
+		}).AddRow(
+			finalizedUUID, time.Now(), uuid.New(), uuid.New(),
+			[]byte(`[{"label":"main.go","filename":"main.go","url":"http://example.com/main.go"}]`),
+			"task", resultDate, true, "OK", 1.0, 1.0, []byte("[]"), []byte("[]"),
+		),
+	)
+	mock.ExpectQuery(`UPDATE Submissions`).WithArgs(missingUUID, true, "OK", 1.0, 1.0, sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT (.+) FROM Submissions`).WithArgs(missingUUID).WillReturnError(sql.ErrNoRows)
+	defer func() {
+		_ = mdb.Close()
+	}()
+
+	tests := []struct {
+		name    string
+		m       *model.Submission
+		wantErr error
+	}{
+		{
+			name:    "update pending submission",
+			m:       &model.Submission{ID: pendingUUID, ResultPass: true, ResultText: "OK", ResultScore: 1, ResultMaxScore: 1},
+			wantErr: nil,
+		},
+		{
+			name:    "update finalized submission",
+			m:       &model.Submission{ID: finalizedUUID, ResultPass: false, ResultText: "FAIL", ResultMaxScore: 1},
+			wantErr: apperr.ErrConflict,
+		},
+		{
+			name:    "update missing submission",
+			m:       &model.Submission{ID: missingUUID, ResultPass: true, ResultText: "OK", ResultScore: 1, ResultMaxScore: 1},
+			wantErr: apperr.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &SubmissionRepository{
+				db: mdb,
+			}
+			got, err := r.UpdateResult(context.TODO(), tt.m)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdateResult() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && !got.HasResult() {
+				t.Errorf("UpdateResult() got = %v, want result date set", got)
+			}
+		})
+	}
+}
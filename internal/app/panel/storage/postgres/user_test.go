@@ -115,7 +115,7 @@ func TestUserRepository_Read(t *testing.T) {
 	failingUUID := uuid.New()
 
 	mock.ExpectQuery(`SELECT (.+) FROM users`).WithArgs(goodUUID.String()).WillReturnRows(
-		sqlmock.NewRows([]string{"id", "name"}).AddRow(goodUUID.String(), "Good"),
+		sqlmock.NewRows([]string{"id", "name", "is_admin"}).AddRow(goodUUID.String(), "Good", false),
 	)
 	mock.ExpectQuery(`SELECT (.+) FROM users`).WithArgs(missingUUID.String()).WillReturnError(sql.ErrNoRows)
 	mock.ExpectQuery(`SELECT (.+) FROM users`).WithArgs(failingUUID.String()).WillReturnError(
@@ -192,7 +192,7 @@ func TestUserRepository_ReadByNameAndPassword(t *testing.T) {
 	goodUUID := uuid.New()
 
 	mock.ExpectQuery(`SELECT (.+) FROM users`).WithArgs("Good", "Password").WillReturnRows(
-		sqlmock.NewRows([]string{"id", "name"}).AddRow(goodUUID.String(), "Good"),
+		sqlmock.NewRows([]string{"id", "name", "is_admin"}).AddRow(goodUUID.String(), "Good", false),
 	)
 	mock.ExpectQuery(`SELECT (.+) FROM users`).WithArgs("Good", "BadPassword").WillReturnError(sql.ErrNoRows)
 	mock.ExpectQuery(`SELECT (.+) FROM users`).WithArgs("Failing", "Password").WillReturnError(
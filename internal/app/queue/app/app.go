@@ -1,9 +1,12 @@
 package app
 
 import (
+	"database/sql"
 	"fmt"
+	_ "github.com/lib/pq"
 	"grader/internal/app/queue/config"
 	"grader/internal/app/queue/pkg/sender"
+	"grader/internal/app/queue/storage/postgres"
 	"grader/pkg/logger"
 	"grader/pkg/queue"
 	"grader/pkg/queue/amqp"
@@ -25,13 +28,45 @@ func New(cfg config.Config) (*App, error) {
 
 	wp := workerpool.New()
 
+	db, err := sql.Open("postgres", cfg.DB.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("db open: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("db ping: %w", err)
+	}
+
+	assessments, err := postgres.NewAssessmentRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("assessments repository: %w", err)
+	}
+	submissions, err := postgres.NewSubmissionRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("submissions repository: %w", err)
+	}
+	graders, err := postgres.NewGraderRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("graders repository: %w", err)
+	}
+
 	// init amqp dep
 	q, err := amqp.New(cfg.AMQP)
 	if err != nil {
 		return nil, fmt.Errorf("amqp: %w", err)
 	}
 
-	sndr, err := sender.New(q, cfg.App.TopicName)
+	sndr, err := sender.New(
+		q,
+		cfg.App.TopicName,
+		cfg.Grader.URL,
+		cfg.Panel.URL,
+		assessments,
+		submissions,
+		graders,
+		sender.WithTimeout(cfg.Grader.Timeout),
+		sender.WithSecret(cfg.Grader.Secret),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("sender: %w", err)
 	}
@@ -3,15 +3,35 @@ package config
 import (
 	"grader/pkg/logger"
 	"grader/pkg/queue/amqp"
+	"time"
 )
 
 type Config struct {
-	App    AppConfig     `mapstructure:"app"`
-	AMQP   amqp.Config   `mapstructure:"amqp"`
-	Logger logger.Config `mapstructure:"log"`
+	App    AppConfig      `mapstructure:"app"`
+	AMQP   amqp.Config    `mapstructure:"amqp"`
+	DB     DatabaseConfig `mapstructure:"db"`
+	Grader GraderConfig   `mapstructure:"grader"`
+	Panel  PanelConfig    `mapstructure:"panel"`
+	Logger logger.Config  `mapstructure:"log"`
 }
 
 type AppConfig struct {
 	Name      string `mapstructure:"name"`
 	TopicName string `mapstructure:"topic_name"`
 }
+
+type DatabaseConfig struct {
+	DSN string `mapstructure:"dsn"`
+}
+
+// GraderConfig of the default grader, assessments may be assigned to the registered ones instead
+type GraderConfig struct {
+	URL string `mapstructure:"url"`
+	// Secret presented to the grader as the bearer token, no token is sent if empty
+	Secret  string        `mapstructure:"secret"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+type PanelConfig struct {
+	URL string `mapstructure:"url"`
+}
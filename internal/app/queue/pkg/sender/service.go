@@ -2,69 +2,350 @@ package sender
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog"
+	"grader/internal/app/queue/storage"
+	"grader/internal/pkg/graderapi"
 	"grader/internal/pkg/model"
+	"grader/pkg/apperr"
 	"grader/pkg/logger"
 	"grader/pkg/queue"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultRetryAfter when the busy grader does not suggest a delay
+	defaultRetryAfter = 10 * time.Second
+	maxRetryAfter     = 5 * time.Minute
 )
 
 type Sender struct {
-	topic  queue.Topic
-	client *resty.Client
+	topic       queue.Topic
+	client      *resty.Client
+	assessments storage.AssessmentRepository
+	submissions storage.SubmissionRepository
+	graders     storage.GraderRepository
+	graderURL   string
+	secret      string
+	panelURL    string
+}
+
+type Option func(*Sender)
+
+func WithTimeout(v time.Duration) Option {
+	return func(s *Sender) {
+		s.client.SetTimeout(v)
+	}
+}
+
+// WithSecret presented to the default grader as the bearer token
+func WithSecret(v string) Option {
+	return func(s *Sender) {
+		s.secret = v
+	}
 }
 
 func New(
 	q queue.Queue,
 	topicName string,
+	graderURL string,
+	panelURL string,
+	a storage.AssessmentRepository,
+	sub storage.SubmissionRepository,
+	g storage.GraderRepository,
+	opts ...Option,
 ) (*Sender, error) {
 	t, err := q.Topic(topicName)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Sender{
-		topic:  t,
-		client: resty.New(),
-	}, nil
+	s := &Sender{
+		topic:       t,
+		client:      resty.New(),
+		assessments: a,
+		submissions: sub,
+		graders:     g,
+		graderURL:   strings.TrimRight(graderURL, "/"),
+		panelURL:    strings.TrimRight(panelURL, "/"),
+	}
+
+	for _, o := range opts {
+		o(s)
+	}
+
+	return s, nil
 }
 
 func (s *Sender) Send() error {
-	l := logger.Global()
+	err := s.topic.Consume(model.Submission{}, s.handle)
+
+	if err != nil {
+		return fmt.Errorf("consume: %w", err)
+	}
+
+	return err
+}
+
+// handle the message, the submission is failed if the message is dropped, so it does not stay pending forever
+func (s *Sender) handle(ctx context.Context, message interface{}) error {
+	val, ok := message.(*model.Submission)
+	if !ok {
+		return fmt.Errorf("%w: invalid message type, got %T %#v", queue.ErrPermanent, message, message)
+	}
+
+	err := s.forward(ctx, val)
+	if errors.Is(err, queue.ErrPermanent) {
+		if ferr := s.submissions.Fail(ctx, val.ID, "Submission can not be checked: "+err.Error()); ferr != nil {
+			l := logger.Ctx(ctx)
+			l.Error().Err(ferr).Str("submission_id", val.ID.String()).Msg("Unable to fail dropped submission")
+		}
+	}
+
+	return err
+}
+
+// forward submission to the grader and save received task ID
+func (s *Sender) forward(ctx context.Context, msg *model.Submission) error {
+	l := logger.Ctx(ctx).With().Str("submission_id", msg.ID.String()).Logger()
+
+	sub, err := s.submissions.Read(ctx, msg.ID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return fmt.Errorf("%w: submission: %v", queue.ErrPermanent, err)
+		}
+		return fmt.Errorf("submission: %w", err)
+	}
+
+	as, err := s.assessments.Read(ctx, sub.AssessmentID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return fmt.Errorf("%w: assessment: %v", queue.ErrPermanent, err)
+		}
+		return fmt.Errorf("assessment: %w", err)
+	}
+
+	if as.ContainerImageDigest == "" {
+		return fmt.Errorf("%w: assessment %s image is not pinned, save it again", queue.ErrPermanent, as.ID)
+	}
+
+	cfg := as.GraderConfig()
+
+	t, err := s.target(ctx, cfg.ExternalGrader)
+	if err != nil {
+		return err
+	}
+	if err := s.checkCapacity(ctx, l, t); err != nil {
+		return err
+	}
+
+	req := &graderapi.CheckSubmissionRequest{
+		// redelivered messages must not be graded twice
+		IdempotencyKey: sub.ID.String(),
+		Submission: graderapi.Submission{
+			ContainerImage: as.PinnedImage(),
+			PartID:         cfg.GraderPayload.PartID,
+			PostbackURL:    s.postbackURL(sub),
+			PostbackToken:  msg.CallbackToken,
+			Files:          submissionFiles(sub.Files),
+			Sandbox:        sandbox(cfg.GraderPayload.Sandbox),
+			ResultMode:     cfg.GraderPayload.ResultMode,
+			TestCases:      testCases(cfg.GraderPayload.TestCases),
+		},
+	}
+
+	out := &graderapi.CheckSubmissionResponse{}
+
+	r := s.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(req).
+		SetResult(out)
+	if t.secret != "" {
+		r.SetAuthToken(t.secret)
+	}
+
+	resp, err := r.Post(t.url + "/submissions")
+	if err != nil {
+		// network errors are worth retrying
+		return fmt.Errorf("grader request: %w", err)
+	}
+
+	switch code := resp.StatusCode(); {
+	case code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable:
+		// delayed message does not hold the consumer, submissions of the other graders keep moving
+		wait := retryAfter(resp.Header().Get("Retry-After"))
+		l.Info().Dur("retry_after", wait).Msg("Grader is busy, backing off")
+		return queue.RetryAfter(wait, fmt.Errorf("grader busy: %s", resp.Status()))
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		// wrong or rotated grader secret is fixed by the admin, the submissions wait for it
+		l.Error().Str("grader", t.name).Dur("retry_after", maxRetryAfter).Msg("Grader rejected the secret, backing off")
+		return queue.RetryAfter(maxRetryAfter, fmt.Errorf("grader auth: %s", resp.Status()))
+	case code >= http.StatusInternalServerError:
+		return fmt.Errorf("grader response: %s", resp.Status())
+	case code >= http.StatusBadRequest:
+		return fmt.Errorf("%w: grader response: %s: %s", queue.ErrPermanent, resp.Status(), resp.String())
+	}
+
+	l.Debug().Str("task_id", out.TaskID.String()).Msg("Submission accepted by grader")
+
+	// result callback is accepted for the saved task only, redelivered message gets the same task
+	// from the grader by the idempotency key, so the update is retried safely
+	if err := s.submissions.UpdateExternalID(ctx, sub.ID, out.TaskID.String()); err != nil {
+		return fmt.Errorf("save grader task ID: %w", err)
+	}
+
+	return nil
+}
+
+// target grader the submissions are sent to
+type target struct {
+	// name of the registered grader, empty for the default one
+	name           string
+	url            string
+	secret         string
+	enabled        bool
+	maxConcurrency int
+}
+
+// target grader registered by the name, the default one if the name is empty
+func (s *Sender) target(ctx context.Context, name string) (*target, error) {
+	if name == "" {
+		return &target{url: s.graderURL, secret: s.secret, enabled: true}, nil
+	}
 
-	err := s.topic.Consume(model.Submission{}, func(ctx context.Context, message interface{}) error {
-		val, ok := message.(*model.Submission)
-		if !ok {
-			return fmt.Errorf("invalid message type, got %T %#v", message, message)
+	g, err := s.graders.ReadByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, fmt.Errorf("%w: grader %s is not registered", queue.ErrPermanent, name)
 		}
+		return nil, fmt.Errorf("grader %s: %w", name, err)
+	}
 
-		//req := &runner.Submission{
-		//
-		//}
-
-		/**
-		ContainerImage string           `json:"container_image" validate:"required"`
-		PartID         string           `json:"part_id" validate:"required"`
-		PostbackURL    string           `json:"postback_url" validate:"required,url"`
-		Files          []SubmissionFile `json:"files" validate:"required"`
-		*/
-
-		//_, err := s.client.R().
-		//	SetContext(ctx).
-		//	SetHeader("Content-Type", "application/json").
-		//	SetBody(req).
-		//	Post()
-		//if err != nil {
-		//	return fmt.Errorf("result postback: %w", err)
-		//}
-
-		l.Debug().Msgf("%#v", val)
+	return &target{
+		name:           g.Name,
+		url:            strings.TrimRight(g.URL, "/"),
+		secret:         g.Secret,
+		enabled:        g.Enabled,
+		maxConcurrency: g.MaxConcurrency,
+	}, nil
+}
+
+// checkCapacity of the grader, the message is delayed if the grader can not take it
+func (s *Sender) checkCapacity(ctx context.Context, l zerolog.Logger, t *target) error {
+	if !t.enabled {
+		l.Info().Str("grader", t.name).Dur("retry_after", defaultRetryAfter).Msg("Grader is disabled, backing off")
+		return queue.RetryAfter(defaultRetryAfter, fmt.Errorf("grader %s is disabled", t.name))
+	}
+	if t.maxConcurrency <= 0 {
 		return nil
-	})
+	}
 
+	// best effort, concurrent consumers may go over the limit by the number of them
+	n, err := s.activeTasks(ctx, t)
 	if err != nil {
-		return fmt.Errorf("consume: %w", err)
+		return fmt.Errorf("active tasks: %w", err)
+	}
+	if n >= t.maxConcurrency {
+		l.Info().Str("grader", t.name).Int("active", n).Dur("retry_after", defaultRetryAfter).Msg("Grader is at capacity, backing off")
+		return queue.RetryAfter(defaultRetryAfter, fmt.Errorf("grader %s is at capacity of %d submissions", t.name, t.maxConcurrency))
 	}
 
-	return err
+	return nil
+}
+
+// activeTasks queued or running on the grader, lost tasks are not reported by it
+func (s *Sender) activeTasks(ctx context.Context, t *target) (int, error) {
+	n := 0
+	for _, status := range []string{graderapi.TaskStatusQueued, graderapi.TaskStatusRunning} {
+		out := &graderapi.ListTasksResponse{}
+
+		r := s.client.R().
+			SetContext(ctx).
+			SetQueryParam("status", status).
+			SetResult(out)
+		if t.secret != "" {
+			r.SetAuthToken(t.secret)
+		}
+
+		resp, err := r.Get(t.url + "/submissions")
+		if err != nil {
+			return 0, fmt.Errorf("grader request: %w", err)
+		}
+		if resp.IsError() {
+			return 0, fmt.Errorf("grader response: %s", resp.Status())
+		}
+
+		n += len(out.Tasks)
+	}
+
+	return n, nil
+}
+
+// retryAfter delay from the header in seconds, bounded to keep the message moving
+func retryAfter(v string) time.Duration {
+	sec, err := strconv.Atoi(v)
+	if err != nil || sec <= 0 {
+		return defaultRetryAfter
+	}
+
+	d := time.Duration(sec) * time.Second
+	if d > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return d
+}
+
+// postbackURL for the panel result callback of the submission
+func (s *Sender) postbackURL(m *model.Submission) string {
+	return fmt.Sprintf("%s/api/submissions/%s/result", s.panelURL, m.ID.String())
+}
+
+func submissionFiles(in model.SubmissionFiles) []graderapi.SubmissionFile {
+	out := make([]graderapi.SubmissionFile, 0, len(in))
+	for _, f := range in {
+		out = append(out, graderapi.SubmissionFile{Name: f.FileName, URL: f.URL})
+	}
+
+	return out
+}
+
+func sandbox(in *model.Sandbox) *graderapi.Sandbox {
+	if in == nil {
+		return nil
+	}
+
+	return &graderapi.Sandbox{
+		NetworkMode: in.NetworkMode,
+		MemoryMB:    in.MemoryMB,
+		CPUs:        in.CPUs,
+		PidsLimit:   in.PidsLimit,
+		Timeout:     in.Timeout,
+	}
+}
+
+func testCases(in model.TestCases) []graderapi.TestCase {
+	if len(in) == 0 {
+		return nil
+	}
+
+	out := make([]graderapi.TestCase, 0, len(in))
+	for _, tc := range in {
+		out = append(out, graderapi.TestCase{
+			Name:      tc.Name,
+			Input:     tc.Input,
+			Expected:  tc.Expected,
+			Compare:   tc.Compare,
+			Tolerance: tc.Tolerance,
+			Hidden:    tc.Hidden,
+			Weight:    tc.Weight,
+		})
+	}
+
+	return out
 }
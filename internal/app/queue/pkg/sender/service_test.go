@@ -0,0 +1,261 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"grader/internal/pkg/graderapi"
+	"grader/internal/pkg/model"
+	"grader/pkg/apperr"
+	"grader/pkg/queue"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeQueue struct{}
+
+func (fakeQueue) Topic(string) (queue.Topic, error) {
+	return nil, nil
+}
+
+type fakeAssessments map[uuid.UUID]*model.Assessment
+
+func (f fakeAssessments) Read(_ context.Context, id uuid.UUID) (*model.Assessment, error) {
+	if m, ok := f[id]; ok {
+		return m, nil
+	}
+	return nil, apperr.ErrNotFound
+}
+
+type fakeSubmissions struct {
+	models      map[uuid.UUID]*model.Submission
+	externalIDs map[uuid.UUID]string
+	failures    map[uuid.UUID]string
+	updateErr   error
+}
+
+func (f *fakeSubmissions) Read(_ context.Context, id uuid.UUID) (*model.Submission, error) {
+	if m, ok := f.models[id]; ok {
+		return m, nil
+	}
+	return nil, apperr.ErrNotFound
+}
+
+func (f *fakeSubmissions) UpdateExternalID(_ context.Context, id uuid.UUID, externalID string) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	f.externalIDs[id] = externalID
+	return nil
+}
+
+func (f *fakeSubmissions) Fail(_ context.Context, id uuid.UUID, text string) error {
+	f.failures[id] = text
+	return nil
+}
+
+type fakeGraders map[string]*model.Grader
+
+func (f fakeGraders) ReadByName(_ context.Context, name string) (*model.Grader, error) {
+	if g, ok := f[name]; ok {
+		return g, nil
+	}
+	return nil, apperr.ErrNotFound
+}
+
+func TestSender_forward(t *testing.T) {
+	taskID := uuid.New()
+
+	tests := []struct {
+		name       string
+		grader     string
+		digest     string
+		submission bool
+		// status and header of the grader response to the submission
+		status     int
+		retryAfter string
+		// active tasks reported by the grader
+		active int
+
+		wantRetry     time.Duration
+		wantPermanent bool
+		wantErr       bool
+		wantTaskID    string
+	}{
+		{name: "accepted", digest: "sha256:abc", submission: true, status: http.StatusAccepted, wantTaskID: taskID.String()},
+		{name: "accepted by registered grader", grader: "external", digest: "sha256:abc", submission: true, status: http.StatusAccepted, active: 1, wantTaskID: taskID.String()},
+		{name: "too many requests", digest: "sha256:abc", submission: true, status: http.StatusTooManyRequests, retryAfter: "3", wantRetry: 3 * time.Second},
+		{name: "unavailable without delay", digest: "sha256:abc", submission: true, status: http.StatusServiceUnavailable, wantRetry: defaultRetryAfter},
+		{name: "unavailable with long delay", digest: "sha256:abc", submission: true, status: http.StatusServiceUnavailable, retryAfter: "3600", wantRetry: maxRetryAfter},
+		{name: "server error", digest: "sha256:abc", submission: true, status: http.StatusInternalServerError, wantErr: true},
+		{name: "unauthorized", digest: "sha256:abc", submission: true, status: http.StatusUnauthorized, wantRetry: maxRetryAfter},
+		{name: "forbidden", grader: "external", digest: "sha256:abc", submission: true, status: http.StatusForbidden, wantRetry: maxRetryAfter},
+		{name: "bad request", digest: "sha256:abc", submission: true, status: http.StatusBadRequest, wantPermanent: true},
+		{name: "submission not found", digest: "sha256:abc", status: http.StatusAccepted, wantPermanent: true},
+		{name: "image not pinned", submission: true, status: http.StatusAccepted, wantPermanent: true},
+		{name: "grader not registered", grader: "unknown", digest: "sha256:abc", submission: true, status: http.StatusAccepted, wantPermanent: true},
+		{name: "grader disabled", grader: "disabled", digest: "sha256:abc", submission: true, status: http.StatusAccepted, wantRetry: defaultRetryAfter},
+		{name: "grader at capacity", grader: "external", digest: "sha256:abc", submission: true, status: http.StatusAccepted, active: 2, wantRetry: defaultRetryAfter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *graderapi.CheckSubmissionRequest
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					// half of the active tasks are queued, the rest are running
+					out := &graderapi.ListTasksResponse{Tasks: []graderapi.Task{}}
+					n := tt.active / 2
+					if r.URL.Query().Get("status") == graderapi.TaskStatusRunning {
+						n = tt.active - n
+					}
+					for i := 0; i < n; i++ {
+						out.Tasks = append(out.Tasks, graderapi.Task{ID: uuid.New(), Status: r.URL.Query().Get("status")})
+					}
+					w.Header().Set("Content-Type", "application/json")
+					_ = json.NewEncoder(w).Encode(out)
+					return
+				}
+
+				got = &graderapi.CheckSubmissionRequest{}
+				_ = json.NewDecoder(r.Body).Decode(got)
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_ = json.NewEncoder(w).Encode(&graderapi.CheckSubmissionResponse{TaskID: taskID})
+			}))
+			defer srv.Close()
+
+			as := &model.Assessment{
+				ID:                   uuid.New(),
+				ContainerImage:       "grader/go:1.17",
+				ContainerImageDigest: tt.digest,
+				PartID:               "hw1",
+				ExternalGrader:       tt.grader,
+			}
+			sub := &model.Submission{
+				ID:           uuid.New(),
+				AssessmentID: as.ID,
+				Files:        model.SubmissionFiles{{FileName: "main.go", URL: "http://s3/main.go"}},
+			}
+			subs := &fakeSubmissions{models: map[uuid.UUID]*model.Submission{}, externalIDs: map[uuid.UUID]string{}, failures: map[uuid.UUID]string{}}
+			if tt.submission {
+				subs.models[sub.ID] = sub
+			}
+			graders := fakeGraders{
+				"external": {Name: "external", URL: srv.URL, Enabled: true, MaxConcurrency: 2},
+				"disabled": {Name: "disabled", URL: srv.URL},
+			}
+
+			s, err := New(fakeQueue{}, "test", srv.URL, "http://panel/", fakeAssessments{as.ID: as}, subs, graders)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.forward(context.Background(), &model.Submission{ID: sub.ID, CallbackToken: "token"})
+
+			var retryErr *queue.RetryError
+			switch {
+			case tt.wantRetry > 0:
+				if !errors.As(err, &retryErr) || retryErr.After != tt.wantRetry {
+					t.Fatalf("forward() error = %v, want retry after %s", err, tt.wantRetry)
+				}
+			case tt.wantPermanent:
+				if !errors.Is(err, queue.ErrPermanent) {
+					t.Fatalf("forward() error = %v, want %v", err, queue.ErrPermanent)
+				}
+			case tt.wantErr:
+				if err == nil || errors.Is(err, queue.ErrPermanent) || errors.As(err, &retryErr) {
+					t.Fatalf("forward() error = %v, want temporary error", err)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("forward() error = %v", err)
+				}
+			}
+
+			if subs.externalIDs[sub.ID] != tt.wantTaskID {
+				t.Errorf("external id = %q, want %q", subs.externalIDs[sub.ID], tt.wantTaskID)
+			}
+			if tt.wantTaskID == "" {
+				return
+			}
+
+			if got.IdempotencyKey != sub.ID.String() {
+				t.Errorf("idempotency key = %q, want %q", got.IdempotencyKey, sub.ID)
+			}
+			if want := "grader/go:1.17@sha256:abc"; got.Submission.ContainerImage != want {
+				t.Errorf("container image = %q, want %q", got.Submission.ContainerImage, want)
+			}
+			if want := "http://panel/api/submissions/" + sub.ID.String() + "/result"; got.Submission.PostbackURL != want {
+				t.Errorf("postback url = %q, want %q", got.Submission.PostbackURL, want)
+			}
+			if got.Submission.PostbackToken != "token" {
+				t.Errorf("postback token = %q, want %q", got.Submission.PostbackToken, "token")
+			}
+			if len(got.Submission.Files) != 1 || got.Submission.Files[0].Name != "main.go" {
+				t.Errorf("files = %+v, want main.go", got.Submission.Files)
+			}
+		})
+	}
+}
+
+func TestSender_handle(t *testing.T) {
+	taskID := uuid.New()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(&graderapi.CheckSubmissionResponse{TaskID: taskID})
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		digest      string
+		updateErr   error
+		wantErr     bool
+		wantFailure string
+	}{
+		{name: "forwarded", digest: "sha256:abc"},
+		{name: "image not pinned", wantErr: true, wantFailure: "image is not pinned"},
+		{name: "task id not saved", digest: "sha256:abc", updateErr: errors.New("db is down"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := &model.Assessment{ID: uuid.New(), ContainerImage: "grader/go:1.17", ContainerImageDigest: tt.digest, PartID: "hw1"}
+			sub := &model.Submission{ID: uuid.New(), AssessmentID: as.ID}
+			subs := &fakeSubmissions{
+				models:      map[uuid.UUID]*model.Submission{sub.ID: sub},
+				externalIDs: map[uuid.UUID]string{},
+				failures:    map[uuid.UUID]string{},
+				updateErr:   tt.updateErr,
+			}
+
+			s, err := New(fakeQueue{}, "test", srv.URL, "http://panel/", fakeAssessments{as.ID: as}, subs, fakeGraders{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.handle(context.Background(), &model.Submission{ID: sub.ID})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("handle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// only dropped messages fail the submission, the other ones are retried
+			failure, failed := subs.failures[sub.ID]
+			if failed != (tt.wantFailure != "") || !strings.Contains(failure, tt.wantFailure) {
+				t.Errorf("failure = %q, want %q", failure, tt.wantFailure)
+			}
+			if failed && !errors.Is(err, queue.ErrPermanent) {
+				t.Errorf("handle() error = %v, want %v", err, queue.ErrPermanent)
+			}
+		})
+	}
+}
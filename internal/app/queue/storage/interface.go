@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"grader/internal/pkg/model"
+)
+
+type AssessmentRepository interface {
+	// Read instance of model.Assessment
+	Read(ctx context.Context, id uuid.UUID) (*model.Assessment, error)
+}
+
+type SubmissionRepository interface {
+	// Read instance of model.Submission
+	Read(ctx context.Context, id uuid.UUID) (*model.Submission, error)
+	// UpdateExternalID of model.Submission to the grader task ID
+	UpdateExternalID(ctx context.Context, id uuid.UUID, externalID string) error
+	// Fail model.Submission with the result text unless it is already finalized
+	Fail(ctx context.Context, id uuid.UUID, text string) error
+}
+
+type GraderRepository interface {
+	// ReadByName instance of model.Grader
+	ReadByName(ctx context.Context, name string) (*model.Grader, error)
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"grader/internal/app/queue/storage"
+	"grader/internal/pkg/model"
+	"grader/pkg/apperr"
+)
+
+// storage.AssessmentRepository interface implementation
+var _ storage.AssessmentRepository = (*AssessmentRepository)(nil)
+
+type AssessmentRepository struct {
+	db *sql.DB
+}
+
+func NewAssessmentRepository(db *sql.DB) (*AssessmentRepository, error) {
+	s := &AssessmentRepository{
+		db: db,
+	}
+
+	return s, nil
+}
+
+// Read implementation of interface storage.AssessmentRepository
+func (r *AssessmentRepository) Read(ctx context.Context, id uuid.UUID) (*model.Assessment, error) {
+	const SQL = `
+		SELECT id, created_at, part_id, container_image, container_image_digest, summary, files, sandbox, result_mode, test_cases, external_grader
+		FROM assessments 
+		WHERE id=$1
+`
+	m := &model.Assessment{}
+
+	err := r.db.QueryRowContext(ctx, SQL, id).Scan(
+		&m.ID,
+		&m.CreatedAt,
+		&m.PartID,
+		&m.ContainerImage,
+		&m.ContainerImageDigest,
+		&m.Summary,
+		&m.Files,
+		&m.Sandbox,
+		&m.ResultMode,
+		&m.TestCases,
+		&m.ExternalGrader,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.ErrNotFound
+		}
+		return nil, fmt.Errorf("select: %w", err)
+	}
+
+	return m, nil
+}
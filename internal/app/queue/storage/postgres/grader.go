@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"grader/internal/app/queue/storage"
+	"grader/internal/pkg/model"
+	"grader/pkg/apperr"
+)
+
+// storage.GraderRepository interface implementation
+var _ storage.GraderRepository = (*GraderRepository)(nil)
+
+type GraderRepository struct {
+	db *sql.DB
+}
+
+func NewGraderRepository(db *sql.DB) (*GraderRepository, error) {
+	s := &GraderRepository{
+		db: db,
+	}
+
+	return s, nil
+}
+
+// ReadByName implementation of interface storage.GraderRepository
+func (r *GraderRepository) ReadByName(ctx context.Context, name string) (*model.Grader, error) {
+	const SQL = `
+		SELECT id, created_at, name, url, secret, enabled, max_concurrency
+		FROM graders
+		WHERE name=$1
+`
+	m := &model.Grader{}
+
+	err := r.db.QueryRowContext(ctx, SQL, name).Scan(
+		&m.ID,
+		&m.CreatedAt,
+		&m.Name,
+		&m.URL,
+		&m.Secret,
+		&m.Enabled,
+		&m.MaxConcurrency,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.ErrNotFound
+		}
+		return nil, fmt.Errorf("select: %w", err)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"grader/internal/app/queue/storage"
+	"grader/internal/pkg/model"
+	"grader/pkg/apperr"
+)
+
+// storage.SubmissionRepository interface implementation
+var _ storage.SubmissionRepository = (*SubmissionRepository)(nil)
+
+type SubmissionRepository struct {
+	db *sql.DB
+}
+
+func NewSubmissionRepository(db *sql.DB) (*SubmissionRepository, error) {
+	s := &SubmissionRepository{
+		db: db,
+	}
+
+	return s, nil
+}
+
+// Read implementation of interface storage.SubmissionRepository
+func (r *SubmissionRepository) Read(ctx context.Context, id uuid.UUID) (*model.Submission, error) {
+	const SQL = `
+		SELECT
+			id,
+			created_at,
+			user_id,
+			assessment_id,
+			files,
+			COALESCE(external_id, '')
+		FROM submissions 
+		WHERE id=$1
+`
+	m := &model.Submission{}
+
+	err := r.db.QueryRowContext(ctx, SQL, id).Scan(
+		&m.ID,
+		&m.CreatedAt,
+		&m.UserID,
+		&m.AssessmentID,
+		&m.Files,
+		&m.ExternalID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperr.ErrNotFound
+		}
+		return nil, fmt.Errorf("select: %w", err)
+	}
+
+	return m, nil
+}
+
+// UpdateExternalID implementation of interface storage.SubmissionRepository
+func (r *SubmissionRepository) UpdateExternalID(ctx context.Context, id uuid.UUID, externalID string) error {
+	const SQL = `
+		UPDATE submissions
+		SET external_id=$2
+		WHERE id=$1
+`
+	res, err := r.db.ExecContext(ctx, SQL, id, externalID)
+	if err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if n == 0 {
+		return apperr.ErrNotFound
+	}
+
+	return nil
+}
+
+// Fail implementation of interface storage.SubmissionRepository
+func (r *SubmissionRepository) Fail(ctx context.Context, id uuid.UUID, text string) error {
+	const SQL = `
+		UPDATE submissions
+		SET result_date=NOW(),
+			result_pass=false,
+			result_text=$2
+		WHERE id=$1 AND result_date IS NULL
+`
+	if _, err := r.db.ExecContext(ctx, SQL, id, text); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	return nil
+}
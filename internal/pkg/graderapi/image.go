@@ -0,0 +1,29 @@
+package graderapi
+
+// ResolveImageRequest of POST /images/resolve
+type ResolveImageRequest struct {
+	Image string `json:"image" validate:"required"`
+}
+
+// ResolveImageResponse of POST /images/resolve
+type ResolveImageResponse struct {
+	// Image reference pinned to the digest
+	Image  string `json:"image"`
+	Digest string `json:"digest"`
+}
+
+// PrewarmImagesRequest of POST /images/prewarm
+type PrewarmImagesRequest struct {
+	Images []string `json:"images" validate:"required,min=1,dive,required"`
+}
+
+// PrewarmImagesResponse of POST /images/prewarm
+type PrewarmImagesResponse struct {
+	Images []PrewarmResult `json:"images"`
+}
+
+// PrewarmResult of a single image
+type PrewarmResult struct {
+	Image string `json:"image"`
+	Error string `json:"error,omitempty"`
+}
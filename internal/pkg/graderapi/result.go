@@ -0,0 +1,45 @@
+package graderapi
+
+import (
+	"github.com/google/uuid"
+)
+
+// SubmissionResult posted by the grader to the postback URL of the submission
+type SubmissionResult struct {
+	TaskID   uuid.UUID    `json:"task_id"`
+	Pass     bool         `json:"pass"`
+	Text     string       `json:"text"`
+	Status   string       `json:"status"`
+	Score    float64      `json:"score"`
+	MaxScore float64      `json:"max_score"`
+	Tests    []TestResult `json:"tests,omitempty"`
+	// ExitCode of the grading container, nil if there were many or none
+	ExitCode *int64 `json:"exit_code,omitempty"`
+	// Packages of the go_test result mode
+	Packages []PackageResult `json:"packages,omitempty"`
+}
+
+// TestResult of a single test within the submission
+type TestResult struct {
+	Name     string  `json:"name"`
+	Pass     bool    `json:"pass"`
+	Score    float64 `json:"score"`
+	MaxScore float64 `json:"max_score"`
+	Message  string  `json:"message,omitempty"`
+	Package  string  `json:"package,omitempty"`
+	// Status is pass, fail or skip
+	Status string `json:"status,omitempty"`
+	// Elapsed seconds
+	Elapsed float64 `json:"elapsed,omitempty"`
+	Output  string  `json:"output,omitempty"`
+}
+
+// PackageResult of the go test run
+type PackageResult struct {
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	Elapsed float64 `json:"elapsed"`
+	// Coverage percentage of statements, nil if not reported
+	Coverage *float64 `json:"coverage,omitempty"`
+	Output   string   `json:"output,omitempty"`
+}
@@ -0,0 +1,61 @@
+package graderapi
+
+import (
+	"github.com/google/uuid"
+)
+
+// CheckSubmissionRequest of POST /submissions
+type CheckSubmissionRequest struct {
+	Submission Submission `json:"submission"`
+	// IdempotencyKey makes retried requests return the task created by the first one
+	IdempotencyKey string `json:"idempotency_key,omitempty" validate:"omitempty,max=255"`
+}
+
+// CheckSubmissionResponse of POST /submissions
+type CheckSubmissionResponse struct {
+	TaskID uuid.UUID `json:"task_id"`
+}
+
+// Submission to be graded
+type Submission struct {
+	ContainerImage string           `json:"container_image" validate:"required"`
+	PartID         string           `json:"part_id" validate:"required"`
+	PostbackURL    string           `json:"postback_url" validate:"required,url"`
+	PostbackToken  string           `json:"postback_token" validate:"required"`
+	Files          []SubmissionFile `json:"files" validate:"required"`
+	Sandbox        *Sandbox         `json:"sandbox,omitempty"`
+	ResultMode     string           `json:"result_mode,omitempty" validate:"omitempty,oneof=exit_code json io go_test"`
+	TestCases      []TestCase       `json:"test_cases,omitempty" validate:"required_if=ResultMode io,dive"`
+}
+
+// SubmissionFile downloaded into the submission dir by the name
+type SubmissionFile struct {
+	Name string `json:"name" validate:"required"`
+	URL  string `json:"url" validate:"required,url"`
+}
+
+// Sandbox limits overriding the grader defaults, empty values keep the defaults
+type Sandbox struct {
+	NetworkMode    string  `json:"network_mode,omitempty" validate:"omitempty,oneof=none bridge"`
+	User           string  `json:"user,omitempty"`
+	MemoryMB       int64   `json:"memory_mb,omitempty" validate:"gte=0"`
+	CPUs           float64 `json:"cpus,omitempty" validate:"gte=0"`
+	PidsLimit      int64   `json:"pids_limit,omitempty" validate:"gte=0"`
+	TmpfsMB        int64   `json:"tmpfs_mb,omitempty" validate:"gte=0"`
+	ReadOnlyRootfs *bool   `json:"read_only_rootfs,omitempty"`
+	// Timeout of the container run in seconds
+	Timeout int64 `json:"timeout,omitempty" validate:"gte=0"`
+}
+
+// TestCase of the io result mode
+type TestCase struct {
+	Name      string  `json:"name,omitempty"`
+	Input     string  `json:"input"`
+	Expected  string  `json:"expected"`
+	Compare   string  `json:"compare,omitempty" validate:"omitempty,oneof=exact trim float"`
+	Tolerance float64 `json:"tolerance,omitempty" validate:"gte=0"`
+	// Weight of the test case in the score, defaults to 1
+	Weight float64 `json:"weight,omitempty" validate:"gte=0"`
+	// Hidden test case does not reveal its input and expected output in the report
+	Hidden bool `json:"hidden,omitempty"`
+}
@@ -0,0 +1,21 @@
+package graderapi
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	TaskStatusQueued  = "queued"
+	TaskStatusRunning = "running"
+)
+
+// ListTasksResponse of GET /submissions
+type ListTasksResponse struct {
+	Tasks []Task `json:"tasks"`
+}
+
+// Task of the grader, only the fields the clients rely on
+type Task struct {
+	ID     uuid.UUID `json:"task_id"`
+	Status string    `json:"status"`
+}
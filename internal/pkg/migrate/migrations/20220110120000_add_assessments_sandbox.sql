@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "assessments"
+    ADD COLUMN sandbox JSONB NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "assessments"
+    DROP COLUMN sandbox;
+-- +goose StatementEnd
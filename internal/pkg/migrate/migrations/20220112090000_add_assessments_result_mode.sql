@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "assessments"
+    ADD COLUMN result_mode VARCHAR(32) NOT NULL DEFAULT 'exit_code';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "assessments"
+    DROP COLUMN result_mode;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "assessments"
+    ADD COLUMN test_cases JSONB NOT NULL DEFAULT '[]';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "assessments"
+    DROP COLUMN test_cases;
+-- +goose StatementEnd
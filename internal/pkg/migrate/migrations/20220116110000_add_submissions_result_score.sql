@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "submissions"
+    ADD COLUMN result_score DOUBLE PRECISION,
+    ADD COLUMN result_max_score DOUBLE PRECISION,
+    ADD COLUMN result_tests JSONB NOT NULL DEFAULT '[]';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "submissions"
+    DROP COLUMN result_score,
+    DROP COLUMN result_max_score,
+    DROP COLUMN result_tests;
+-- +goose StatementEnd
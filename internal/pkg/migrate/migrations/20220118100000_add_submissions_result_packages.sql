@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "submissions"
+    ADD COLUMN result_packages JSONB NOT NULL DEFAULT '[]';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "submissions"
+    DROP COLUMN result_packages;
+-- +goose StatementEnd
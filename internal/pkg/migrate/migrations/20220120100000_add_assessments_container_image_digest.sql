@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "assessments"
+    ADD COLUMN container_image_digest VARCHAR(255) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "assessments"
+    DROP COLUMN container_image_digest;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "assessments"
+    ADD COLUMN files JSONB NOT NULL DEFAULT '[]';
+UPDATE "assessments"
+    SET files = jsonb_build_array(jsonb_build_object('label', file_name, 'filename', file_name));
+ALTER TABLE "assessments"
+    DROP COLUMN file_name;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "assessments"
+    ADD COLUMN file_name VARCHAR(255) NOT NULL DEFAULT '';
+UPDATE "assessments"
+    SET file_name = COALESCE(files -> 0 ->> 'filename', '');
+ALTER TABLE "assessments"
+    DROP COLUMN files;
+-- +goose StatementEnd
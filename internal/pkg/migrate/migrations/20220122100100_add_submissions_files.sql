@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE "submissions"
+    ADD COLUMN files JSONB NOT NULL DEFAULT '[]';
+UPDATE "submissions"
+    SET files = jsonb_build_array(jsonb_build_object('label', file_name, 'filename', file_name, 'url', file_url));
+ALTER TABLE "submissions"
+    DROP COLUMN file_name,
+    DROP COLUMN file_url;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "submissions"
+    ADD COLUMN file_name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN file_url VARCHAR(2048) NOT NULL DEFAULT '';
+UPDATE "submissions"
+    SET file_name = COALESCE(files -> 0 ->> 'filename', ''),
+        file_url = COALESCE(files -> 0 ->> 'url', '');
+ALTER TABLE "submissions"
+    DROP COLUMN files;
+-- +goose StatementEnd
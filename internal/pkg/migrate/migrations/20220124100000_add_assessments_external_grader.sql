@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- name of the registered grader, the default grader is used if empty
+ALTER TABLE "assessments"
+    ADD COLUMN external_grader VARCHAR(255) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE "assessments"
+    DROP COLUMN external_grader;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+CREATE TABLE IF NOT EXISTS "graders"
+(
+    id              UUID                   DEFAULT uuid_generate_v4() NOT NULL UNIQUE,
+    created_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    name            VARCHAR(255)  NOT NULL UNIQUE,
+    url             VARCHAR(2048) NOT NULL,
+    secret          VARCHAR(255)  NOT NULL DEFAULT '',
+    enabled         BOOLEAN       NOT NULL DEFAULT TRUE,
+    max_concurrency INTEGER       NOT NULL DEFAULT 0,
+    PRIMARY KEY (id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "graders";
+-- +goose StatementEnd
@@ -1,15 +1,241 @@
 package model
 
 import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
+	"grader/pkg/apperr"
+	"grader/pkg/archive"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
 type Assessment struct {
-	ID             uuid.UUID `json:"id"`
-	CreatedAt      time.Time `json:"created_at"`
-	PartID         string    `json:"part_id"`
-	ContainerImage string    `json:"container_image"`
-	Summary        string    `json:"summary"`
-	FileName       string    `json:"file_name"`
+	ID                   uuid.UUID `json:"id"`
+	CreatedAt            time.Time `json:"created_at"`
+	PartID               string    `json:"part_id"`
+	ContainerImage       string    `json:"container_image"`
+	ContainerImageDigest string    `json:"container_image_digest"`
+	Summary              string    `json:"summary"`
+	ExternalGrader       string    `json:"external_grader"`
+	Files                FileSpecs `json:"files"`
+	Sandbox              Sandbox   `json:"sandbox"`
+	ResultMode           string    `json:"result_mode"`
+	TestCases            TestCases `json:"test_cases"`
+	// ArchivedAt time the assessment was hidden from students, zero if it is active
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// IsArchived reports if the assessment no longer accepts submissions
+func (a *Assessment) IsArchived() bool {
+	return !a.ArchivedAt.IsZero()
+}
+
+// PinnedImage reference of the container image, empty if it is not resolved to a digest
+func (a *Assessment) PinnedImage() string {
+	if a.ContainerImageDigest == "" {
+		return ""
+	}
+
+	name := a.ContainerImage
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+
+	return name + "@" + a.ContainerImageDigest
+}
+
+const (
+	// DefaultMaxFileSize of the uploaded file when the FileSpec does not limit it
+	DefaultMaxFileSize = 5 << 20
+	// DefaultMaxUnpackedSize of the archive contents when the FileSpec does not limit it
+	DefaultMaxUnpackedSize = 20 << 20
+	// MaxArchiveFiles unpacked from a single archive
+	MaxArchiveFiles = 200
+)
+
+// FileSpec of a file the submission consists of
+type FileSpec struct {
+	// Label shown next to the upload field
+	Label string `json:"label"`
+	// FileName the uploaded file must have, any name with the Extension is accepted if empty
+	FileName  string `json:"filename,omitempty"`
+	Extension string `json:"extension,omitempty"`
+	// MaxSize of the file in bytes, DefaultMaxFileSize if zero
+	MaxSize  int64 `json:"max_size,omitempty"`
+	Optional bool  `json:"optional,omitempty"`
+	// Archive of the files, zip or tar.gz, unpacked before grading
+	Archive bool `json:"archive,omitempty"`
+	// Patterns the archive entries must match, either by the path or the base name, e.g. *.go
+	Patterns []string `json:"patterns,omitempty"`
+	// MaxUnpackedSize of the archive contents in bytes, DefaultMaxUnpackedSize if zero
+	MaxUnpackedSize int64 `json:"max_unpacked_size,omitempty"`
+}
+
+// Check the uploaded file name and size against the spec
+func (s FileSpec) Check(name string, size int64) error {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("%w: %s: invalid file name %q", apperr.ErrInvalidInput, s.Title(), name)
+	}
+	if s.Archive && !archive.IsArchive(name) {
+		return fmt.Errorf("%w: %s: zip or tar.gz archive is expected, got %s", apperr.ErrInvalidInput, s.Title(), name)
+	}
+	if s.FileName != "" && name != s.FileName {
+		return fmt.Errorf("%w: %s: file name must be %s, got %s", apperr.ErrInvalidInput, s.Title(), s.FileName, name)
+	}
+	if s.Extension != "" && !strings.HasSuffix(name, s.Extension) {
+		return fmt.Errorf("%w: %s: file extension must be %s, got %s", apperr.ErrInvalidInput, s.Title(), s.Extension, name)
+	}
+	if max := s.MaxFileSize(); size > max {
+		return fmt.Errorf("%w: %s: file size exceeds %d bytes", apperr.ErrInvalidInput, s.Title(), max)
+	}
+	return nil
+}
+
+// CheckEntry of the unpacked archive against the patterns
+func (s FileSpec) CheckEntry(name string) error {
+	for _, p := range s.Patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return nil
+		}
+		if ok, _ := path.Match(p, path.Base(name)); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s: file %s is not allowed in the archive", apperr.ErrInvalidInput, s.Title(), name)
+}
+
+// Limits of the archive unpacking
+func (s FileSpec) Limits() archive.Limits {
+	total := s.MaxUnpackedSize
+	if total <= 0 {
+		total = DefaultMaxUnpackedSize
+	}
+	return archive.Limits{MaxFiles: MaxArchiveFiles, MaxFileSize: DefaultMaxFileSize, MaxTotalSize: total}
+}
+
+// Title of the spec, the label or the file name
+func (s FileSpec) Title() string {
+	if s.Label != "" {
+		return s.Label
+	}
+	if s.FileName != "" {
+		return s.FileName
+	}
+	if s.Archive && s.Extension == "" {
+		return "archive"
+	}
+	return "*" + s.Extension
+}
+
+// MaxFileSize accepted for the spec
+func (s FileSpec) MaxFileSize() int64 {
+	if s.MaxSize > 0 {
+		return s.MaxSize
+	}
+	return DefaultMaxFileSize
+}
+
+type FileSpecs []FileSpec
+
+// validate the specs are usable, every file is told apart by its name or extension
+func (fs FileSpecs) validate(errs *ConfigErrors, path string) {
+	if len(fs) == 0 {
+		errs.add(path, "at least one file is required")
+	}
+
+	names := make(map[string]bool, len(fs))
+	for i, s := range fs {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		if err := s.validate(); err != nil {
+			errs.add(p, "%v", err)
+			continue
+		}
+		if s.FileName != "" && names[s.FileName] {
+			errs.add(p+".filename", "duplicate filename %q", s.FileName)
+		}
+		names[s.FileName] = true
+	}
+}
+
+// validate the spec on its own
+func (s FileSpec) validate() error {
+	switch {
+	case s.FileName == "" && s.Extension == "" && !s.Archive:
+		return errors.New("filename or extension is required")
+	case s.FileName != "" && (s.FileName != filepath.Base(s.FileName) || strings.HasPrefix(s.FileName, ".")):
+		return fmt.Errorf("invalid filename %q", s.FileName)
+	case s.MaxSize < 0:
+		return errors.New("negative max_size")
+	case !s.Archive:
+		return nil
+	case len(s.Patterns) == 0:
+		return errors.New("archive patterns are required")
+	case s.MaxUnpackedSize < 0:
+		return errors.New("negative max_unpacked_size")
+	case s.FileName != "" && !archive.IsArchive(s.FileName):
+		return errors.New("archive filename must be .zip or .tar.gz")
+	}
+	for _, p := range s.Patterns {
+		if _, err := path.Match(p, ""); err != nil || p == "" {
+			return fmt.Errorf("invalid pattern %q", p)
+		}
+	}
+	return nil
+}
+
+// Value implementation of driver.Valuer
+func (fs FileSpecs) Value() (driver.Value, error) {
+	return jsonColumnValue(fs)
+}
+
+// Scan implementation of sql.Scanner
+func (fs *FileSpecs) Scan(src interface{}) error {
+	return jsonColumnScan(src, fs, "file specs")
+}
+
+// Sandbox limits of the grading container, empty values keep the grader defaults
+type Sandbox struct {
+	NetworkMode string  `json:"network_mode,omitempty"`
+	MemoryMB    int64   `json:"memory_mb,omitempty"`
+	CPUs        float64 `json:"cpus,omitempty"`
+	PidsLimit   int64   `json:"pids_limit,omitempty"`
+	Timeout     int64   `json:"timeout,omitempty"`
+}
+
+// Value implementation of driver.Valuer
+func (s Sandbox) Value() (driver.Value, error) {
+	return jsonColumnValue(s)
+}
+
+// Scan implementation of sql.Scanner
+func (s *Sandbox) Scan(src interface{}) error {
+	return jsonColumnScan(src, s, "sandbox")
+}
+
+// TestCase of the io result mode, the container gets Input on stdin and is expected to print Expected
+type TestCase struct {
+	Name      string  `json:"name,omitempty"`
+	Input     string  `json:"input"`
+	Expected  string  `json:"expected"`
+	Compare   string  `json:"compare,omitempty"`
+	Tolerance float64 `json:"tolerance,omitempty"`
+	Hidden    bool    `json:"hidden,omitempty"`
+	// Weight of the test case in the score, defaults to 1
+	Weight float64 `json:"weight,omitempty"`
+}
+
+type TestCases []TestCase
+
+// Value implementation of driver.Valuer
+func (tc TestCases) Value() (driver.Value, error) {
+	return jsonColumnValue(tc)
+}
+
+// Scan implementation of sql.Scanner
+func (tc *TestCases) Scan(src interface{}) error {
+	return jsonColumnScan(src, tc, "test cases")
 }
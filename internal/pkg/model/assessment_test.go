@@ -0,0 +1,86 @@
+package model
+
+import (
+	"errors"
+	"grader/pkg/apperr"
+	"testing"
+)
+
+func TestFileSpec_Check(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     FileSpec
+		fileName string
+		size     int64
+		wantErr  bool
+	}{
+		{name: "file name", spec: FileSpec{FileName: "main.go"}, fileName: "main.go", size: 10},
+		{name: "other name", spec: FileSpec{FileName: "main.go"}, fileName: "game.go", size: 10, wantErr: true},
+		{name: "extension", spec: FileSpec{Extension: ".go"}, fileName: "game.go", size: 10},
+		{name: "other extension", spec: FileSpec{Extension: ".go"}, fileName: "game.py", size: 10, wantErr: true},
+		{name: "hidden file", spec: FileSpec{Extension: ".go"}, fileName: ".go", size: 10, wantErr: true},
+		{name: "path", spec: FileSpec{Extension: ".go"}, fileName: "../main.go", size: 10, wantErr: true},
+		{name: "max size", spec: FileSpec{FileName: "main.go", MaxSize: 10}, fileName: "main.go", size: 11, wantErr: true},
+		{name: "default max size", spec: FileSpec{FileName: "main.go"}, fileName: "main.go", size: DefaultMaxFileSize + 1, wantErr: true},
+		{name: "archive", spec: FileSpec{Archive: true, Patterns: []string{"*.go"}}, fileName: "hw1.tar.gz", size: 10},
+		{name: "not an archive", spec: FileSpec{Archive: true, Patterns: []string{"*.go"}}, fileName: "main.go", size: 10, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.spec.Check(tt.fileName, tt.size)
+			if (err != nil) != tt.wantErr || err != nil && !errors.Is(err, apperr.ErrInvalidInput) {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileSpecs_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		specs   FileSpecs
+		wantErr bool
+	}{
+		{name: "valid", specs: FileSpecs{{Label: "hw1_game/main.go", FileName: "main.go"}, {Extension: ".md", Optional: true}}},
+		{name: "empty", specs: nil, wantErr: true},
+		{name: "no name", specs: FileSpecs{{Label: "main"}}, wantErr: true},
+		{name: "path", specs: FileSpecs{{FileName: "hw1/main.go"}}, wantErr: true},
+		{name: "duplicate", specs: FileSpecs{{FileName: "main.go"}, {FileName: "main.go"}}, wantErr: true},
+		{name: "archive", specs: FileSpecs{{Label: "hw1", Archive: true, Patterns: []string{"*.go", "go.mod"}}}},
+		{name: "archive without patterns", specs: FileSpecs{{Archive: true}}, wantErr: true},
+		{name: "archive bad pattern", specs: FileSpecs{{Archive: true, Patterns: []string{"[*.go"}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs ConfigErrors
+			if tt.specs.validate(&errs, "files"); (len(errs) > 0) != tt.wantErr {
+				t.Errorf("validate() errors = %v, wantErr %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileSpec_CheckEntry(t *testing.T) {
+	spec := FileSpec{Archive: true, Patterns: []string{"*.go", "hw1/go.mod"}}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "base name", path: "hw1/game/game.go"},
+		{name: "path", path: "hw1/go.mod"},
+		{name: "other path", path: "go.mod", wantErr: true},
+		{name: "not allowed", path: "hw1/game.exe", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := spec.CheckEntry(tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("CheckEntry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
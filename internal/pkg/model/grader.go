@@ -0,0 +1,56 @@
+package model
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"grader/pkg/apperr"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+var graderNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Grader registered in the panel, assessments refer to it by the name
+type Grader struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name"`
+	// URL of the grader API
+	URL string `json:"url"`
+	// Secret presented to the grader as the bearer token, no token is sent if empty
+	Secret  string `json:"-"`
+	Enabled bool   `json:"enabled"`
+	// MaxConcurrency of the submissions graded at once, unlimited if zero
+	MaxConcurrency int `json:"max_concurrency"`
+}
+
+// Validate the grader settings
+func (g *Grader) Validate() error {
+	if err := ValidateGraderName(g.Name); err != nil {
+		return err
+	}
+	if err := validateGraderURL(g.URL); err != nil {
+		return err
+	}
+	if g.MaxConcurrency < 0 {
+		return fmt.Errorf("%w: negative max concurrency", apperr.ErrInvalidInput)
+	}
+	return nil
+}
+
+// ValidateGraderName of lowercase letters, digits, '_' and '-'
+func ValidateGraderName(name string) error {
+	if !graderNamePattern.MatchString(name) || len(name) > 255 {
+		return fmt.Errorf("%w: grader name must consist of lowercase letters, digits, '_' and '-', got %q", apperr.ErrInvalidInput, name)
+	}
+	return nil
+}
+
+func validateGraderURL(v string) error {
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: absolute http(s) URL is expected, got %q", apperr.ErrInvalidInput, v)
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/docker/distribution/reference"
+	"grader/pkg/apperr"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// MaxGraderConfigSize of the grader config document
+const MaxGraderConfigSize = 1 << 20
+
+// DefaultResultMode of the assessment when the config does not set it
+const DefaultResultMode = "exit_code"
+
+var (
+	resultModes   = []string{"exit_code", "json", "io", "go_test"}
+	compareModes  = []string{"exact", "trim", "float"}
+	networkModes  = []string{"none", "bridge"}
+	partIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
+
+// GraderConfig document of the assessment, it is edited by admins as a whole and stored on the assessment
+type GraderConfig struct {
+	// ExternalGrader name of the registered Grader the submissions are sent to, the default grader if empty
+	ExternalGrader string        `json:"external_grader,omitempty"`
+	Files          FileSpecs     `json:"files"`
+	GraderPayload  GraderPayload `json:"grader_payload"`
+}
+
+// GraderPayload sent to the grader with every submission
+type GraderPayload struct {
+	// Container image the grading is run in
+	Container  string    `json:"container"`
+	PartID     string    `json:"partId"`
+	ResultMode string    `json:"result_mode,omitempty"`
+	Sandbox    *Sandbox  `json:"sandbox,omitempty"`
+	TestCases  TestCases `json:"test_cases,omitempty"`
+}
+
+// ConfigError of the grader config at the path of the document
+type ConfigError struct {
+	Path string `json:"path"`
+	Msg  string `json:"msg"`
+}
+
+func (e ConfigError) Error() string {
+	if e.Path == "" {
+		return e.Msg
+	}
+	return e.Path + ": " + e.Msg
+}
+
+// ConfigErrors found in the grader config, all of them are reported at once
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return "grader config: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap implementation of errors.Unwrap, the config is the user input
+func (e ConfigErrors) Unwrap() error {
+	return apperr.ErrInvalidInput
+}
+
+func (e *ConfigErrors) add(path string, format string, args ...interface{}) {
+	*e = append(*e, ConfigError{Path: path, Msg: fmt.Sprintf(format, args...)})
+}
+
+// ParseGraderConfig document and validate it, unknown fields are refused, ConfigErrors are returned on invalid input
+func ParseGraderConfig(data []byte) (*GraderConfig, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	c := &GraderConfig{}
+	if err := dec.Decode(c); err != nil {
+		return nil, ConfigErrors{decodeError(data, err)}
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return nil, ConfigErrors{{Msg: "unexpected data after the document"}}
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// decodeError described by its position in the document
+func decodeError(data []byte, err error) ConfigError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		// offset is past the offending character
+		line, col := position(data, syntaxErr.Offset-1)
+		return ConfigError{Msg: fmt.Sprintf("line %d, column %d: %v", line, col, syntaxErr)}
+	case errors.As(err, &typeErr):
+		return ConfigError{Path: typeErr.Field, Msg: fmt.Sprintf("%s is expected, got %s", jsonType(typeErr.Type), typeErr.Value)}
+	case errors.Is(err, io.EOF):
+		return ConfigError{Msg: "document is empty"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return ConfigError{Msg: "unexpected end of the document"}
+	default:
+		// unknown fields are reported by the decoder without the path
+		return ConfigError{Msg: strings.TrimPrefix(err.Error(), "json: ")}
+	}
+}
+
+// position of the character at the offset as 1-based line and column
+func position(data []byte, offset int64) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// jsonType name of the Go type
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// Validate the config against the schema, every error found is reported
+func (c *GraderConfig) Validate() error {
+	var errs ConfigErrors
+
+	if c.ExternalGrader != "" && !graderNamePattern.MatchString(c.ExternalGrader) {
+		errs.add("external_grader", "grader name of lowercase letters, digits, '_' and '-' is expected, got %q", c.ExternalGrader)
+	}
+
+	c.Files.validate(&errs, "files")
+
+	c.GraderPayload.validate(&errs, "grader_payload")
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (p GraderPayload) validate(errs *ConfigErrors, path string) {
+	if p.Container == "" {
+		errs.add(path+".container", "is required")
+	} else if _, err := reference.ParseNormalizedNamed(p.Container); err != nil {
+		errs.add(path+".container", "invalid image reference %q: %v", p.Container, err)
+	}
+
+	if p.PartID == "" {
+		errs.add(path+".partId", "is required")
+	} else if !partIDPattern.MatchString(p.PartID) {
+		errs.add(path+".partId", "only letters, digits, '_', '-' and '.' are allowed, got %q", p.PartID)
+	}
+
+	if p.ResultMode != "" && !oneOf(p.ResultMode, resultModes) {
+		errs.add(path+".result_mode", "one of %s is expected, got %q", strings.Join(resultModes, ", "), p.ResultMode)
+	}
+
+	if p.Sandbox != nil {
+		p.Sandbox.validate(errs, path+".sandbox")
+	}
+
+	if p.ResultMode == "io" && len(p.TestCases) == 0 {
+		errs.add(path+".test_cases", "at least one test case is required for the io result mode")
+	}
+	for i, tc := range p.TestCases {
+		tcPath := fmt.Sprintf("%s.test_cases[%d]", path, i)
+		if tc.Compare != "" && !oneOf(tc.Compare, compareModes) {
+			errs.add(tcPath+".compare", "one of %s is expected, got %q", strings.Join(compareModes, ", "), tc.Compare)
+		}
+		if tc.Tolerance < 0 {
+			errs.add(tcPath+".tolerance", "must not be negative")
+		}
+		if tc.Weight < 0 {
+			errs.add(tcPath+".weight", "must not be negative")
+		}
+	}
+}
+
+func (s Sandbox) validate(errs *ConfigErrors, path string) {
+	if s.NetworkMode != "" && !oneOf(s.NetworkMode, networkModes) {
+		errs.add(path+".network_mode", "one of %s is expected, got %q", strings.Join(networkModes, ", "), s.NetworkMode)
+	}
+	if s.MemoryMB < 0 {
+		errs.add(path+".memory_mb", "must not be negative")
+	}
+	if s.CPUs < 0 {
+		errs.add(path+".cpus", "must not be negative")
+	}
+	if s.PidsLimit < 0 {
+		errs.add(path+".pids_limit", "must not be negative")
+	}
+	if s.Timeout < 0 {
+		errs.add(path+".timeout", "must not be negative")
+	}
+}
+
+// Apply the config to the assessment, the image digest is reset when the image is changed
+func (c *GraderConfig) Apply(a *Assessment) {
+	p := c.GraderPayload
+
+	if a.ContainerImage != p.Container {
+		a.ContainerImageDigest = ""
+	}
+
+	a.ExternalGrader = c.ExternalGrader
+	a.Files = c.Files
+	a.ContainerImage = p.Container
+	a.PartID = p.PartID
+	a.ResultMode = p.ResultMode
+	if a.ResultMode == "" {
+		a.ResultMode = DefaultResultMode
+	}
+	a.Sandbox = Sandbox{}
+	if p.Sandbox != nil {
+		a.Sandbox = *p.Sandbox
+	}
+	a.TestCases = p.TestCases
+}
+
+// GraderConfig document of the assessment
+func (a *Assessment) GraderConfig() *GraderConfig {
+	c := &GraderConfig{
+		ExternalGrader: a.ExternalGrader,
+		Files:          a.Files,
+		GraderPayload: GraderPayload{
+			Container:  a.ContainerImage,
+			PartID:     a.PartID,
+			ResultMode: a.ResultMode,
+			TestCases:  a.TestCases,
+		},
+	}
+	if a.Sandbox != (Sandbox{}) {
+		sandbox := a.Sandbox
+		c.GraderPayload.Sandbox = &sandbox
+	}
+
+	return c
+}
+
+func oneOf(v string, values []string) bool {
+	for _, s := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"errors"
+	"grader/pkg/apperr"
+	"reflect"
+	"testing"
+)
+
+func TestParseGraderConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantErrs ConfigErrors
+	}{
+		{
+			name: "requirements example",
+			data: `{
+				"external_grader": "golangcourse",
+				"files": [{"label": "hw1_game/main.go", "filename": "main.go"}],
+				"grader_payload": {"container": "golangcourse_final", "partId": "HW1_game"}
+			}`,
+		},
+		{
+			name:     "syntax",
+			data:     "{\n  \"files\": [}\n}",
+			wantErrs: ConfigErrors{{Msg: "line 2, column 13: invalid character '}' looking for beginning of value"}},
+		},
+		{
+			name:     "type",
+			data:     `{"files": [{"filename": "main.go"}], "grader_payload": {"container": "grader", "partId": 1}}`,
+			wantErrs: ConfigErrors{{Path: "grader_payload.partId", Msg: "string is expected, got number"}},
+		},
+		{
+			name:     "unknown field",
+			data:     `{"files": [{"filename": "main.go"}], "grader_payload": {"container": "grader", "part_id": "HW1"}}`,
+			wantErrs: ConfigErrors{{Msg: `unknown field "part_id"`}},
+		},
+		{
+			name:     "trailing data",
+			data:     `{"files": [{"filename": "main.go"}], "grader_payload": {"container": "grader", "partId": "HW1"}} {}`,
+			wantErrs: ConfigErrors{{Msg: "unexpected data after the document"}},
+		},
+		{
+			name: "invalid",
+			data: `{
+				"external_grader": "grader:8021",
+				"files": [{"label": "main"}],
+				"grader_payload": {
+					"container": "Grader",
+					"partId": "HW1 game",
+					"result_mode": "io",
+					"sandbox": {"network_mode": "host"}
+				}
+			}`,
+			wantErrs: ConfigErrors{
+				{Path: "external_grader", Msg: `grader name of lowercase letters, digits, '_' and '-' is expected, got "grader:8021"`},
+				{Path: "files[0]", Msg: "filename or extension is required"},
+				{Path: "grader_payload.container", Msg: `invalid image reference "Grader": invalid reference format: repository name must be lowercase`},
+				{Path: "grader_payload.partId", Msg: `only letters, digits, '_', '-' and '.' are allowed, got "HW1 game"`},
+				{Path: "grader_payload.sandbox.network_mode", Msg: `one of none, bridge is expected, got "host"`},
+				{Path: "grader_payload.test_cases", Msg: "at least one test case is required for the io result mode"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGraderConfig([]byte(tt.data))
+
+			var errs ConfigErrors
+			if err != nil && (!errors.As(err, &errs) || !errors.Is(err, apperr.ErrInvalidInput)) {
+				t.Fatalf("ParseGraderConfig() error = %v, want ConfigErrors", err)
+			}
+			if !reflect.DeepEqual(errs, tt.wantErrs) {
+				t.Errorf("ParseGraderConfig() errors = %#v, want %#v", errs, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestGraderConfig_Apply(t *testing.T) {
+	a := &Assessment{ContainerImage: "grader:v1", ContainerImageDigest: "sha256:abc", Sandbox: Sandbox{Timeout: 10}}
+
+	c := a.GraderConfig()
+	c.GraderPayload.Container = "grader:v2"
+	c.GraderPayload.Sandbox = nil
+	c.Apply(a)
+
+	if a.ContainerImageDigest != "" || a.Sandbox != (Sandbox{}) || a.ResultMode != DefaultResultMode {
+		t.Errorf("Apply() = %+v", a)
+	}
+}
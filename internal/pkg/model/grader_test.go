@@ -0,0 +1,30 @@
+package model
+
+import (
+	"errors"
+	"grader/pkg/apperr"
+	"testing"
+)
+
+func TestGrader_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		grader  Grader
+		wantErr bool
+	}{
+		{name: "valid", grader: Grader{Name: "golang-course_2", URL: "https://grader.example.com/api", MaxConcurrency: 4}},
+		{name: "uppercase name", grader: Grader{Name: "Golang", URL: "http://grader"}, wantErr: true},
+		{name: "empty name", grader: Grader{URL: "http://grader"}, wantErr: true},
+		{name: "relative url", grader: Grader{Name: "golang", URL: "grader:8090"}, wantErr: true},
+		{name: "negative concurrency", grader: Grader{Name: "golang", URL: "http://grader", MaxConcurrency: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.grader.Validate()
+			if (err != nil) != tt.wantErr || err != nil && !errors.Is(err, apperr.ErrInvalidInput) {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// jsonColumnValue of v for a JSONB column, nil slices are stored as empty arrays
+func jsonColumnValue(v interface{}) (driver.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if string(b) == "null" {
+		return []byte("[]"), nil
+	}
+	return b, nil
+}
+
+// jsonColumnScan of a JSONB column into dst pointer, NULL resets it to the zero value, name is used in errors
+func jsonColumnScan(src interface{}, dst interface{}, name string) error {
+	switch v := src.(type) {
+	case nil:
+		e := reflect.ValueOf(dst).Elem()
+		e.Set(reflect.Zero(e.Type()))
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dst)
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	default:
+		return fmt.Errorf("unsupported %s type %T", name, src)
+	}
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestJSONColumn_Value(t *testing.T) {
+	tests := []struct {
+		name  string
+		value driver.Valuer
+		want  string
+	}{
+		{name: "nil slice", value: TestCases(nil), want: `[]`},
+		{name: "slice", value: SubmissionFiles{{Label: "main", FileName: "main.go", URL: "u"}}, want: `[{"label":"main","filename":"main.go","url":"u"}]`},
+		{name: "empty struct", value: Sandbox{}, want: `{}`},
+		{name: "struct", value: Sandbox{MemoryMB: 256}, want: `{"memory_mb":256}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.value.Value()
+			if err != nil {
+				t.Fatalf("Value() error = %v", err)
+			}
+			if b, ok := got.([]byte); !ok || string(b) != tt.want {
+				t.Errorf("Value() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONColumn_Scan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		dst     sql.Scanner
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "bytes", src: []byte(`[{"name":"a"}]`), dst: &TestResults{}, want: &TestResults{{Name: "a"}}},
+		{name: "string", src: `{"pids_limit":64}`, dst: &Sandbox{}, want: &Sandbox{PidsLimit: 64}},
+		{name: "null slice", src: nil, dst: &TestResults{{Name: "a"}}, want: new(TestResults)},
+		{name: "null struct", src: nil, dst: &Sandbox{CPUs: 1}, want: &Sandbox{}},
+		{name: "unsupported", src: 42, dst: &PackageResults{}, wantErr: true},
+		{name: "invalid json", src: `{`, dst: &FileSpecs{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.dst.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.dst, tt.want) {
+				t.Errorf("Scan() = %+v, want %+v", tt.dst, tt.want)
+			}
+		})
+	}
+}
@@ -1,19 +1,101 @@
 package model
 
 import (
+	"database/sql/driver"
 	"github.com/google/uuid"
 	"time"
 )
 
 type Submission struct {
-	ID           uuid.UUID `json:"id"`
-	CreatedAt    time.Time `json:"created_at"`
-	UserID       uuid.UUID `json:"user_id"`
-	AssessmentID uuid.UUID `json:"assessment_id"`
-	FileName     string    `json:"file_name"`
-	FileURL      string    `json:"file_url"`
-	ExternalID   string    `json:"external_id"`
-	ResultDate   time.Time `json:"result_date"`
-	ResultPass   bool      `json:"result_pass"`
-	ResultText   string    `json:"result_text"`
+	ID           uuid.UUID       `json:"id"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UserID       uuid.UUID       `json:"user_id"`
+	AssessmentID uuid.UUID       `json:"assessment_id"`
+	Files        SubmissionFiles `json:"files"`
+	ExternalID   string          `json:"external_id"`
+	ResultDate   time.Time       `json:"result_date"`
+	ResultPass   bool            `json:"result_pass"`
+	ResultText   string          `json:"result_text"`
+	// ResultScore of ResultMaxScore points awarded by the grader
+	ResultScore    float64     `json:"result_score"`
+	ResultMaxScore float64     `json:"result_max_score"`
+	ResultTests    TestResults `json:"result_tests"`
+	// ResultPackages of the go test run
+	ResultPackages PackageResults `json:"result_packages"`
+	// CallbackToken authorizes grader result postback, it travels with the queue message and is never stored
+	CallbackToken string `json:"callback_token,omitempty"`
+}
+
+func (m *Submission) Identity() string {
+	return m.ID.String()
+}
+
+// HasResult reports if the grader verdict was already recorded
+func (m *Submission) HasResult() bool {
+	return !m.ResultDate.IsZero()
+}
+
+// SubmissionFile uploaded for the FileSpec of the assessment
+type SubmissionFile struct {
+	Label    string `json:"label"`
+	FileName string `json:"filename"`
+	URL      string `json:"url"`
+}
+
+type SubmissionFiles []SubmissionFile
+
+// Value implementation of driver.Valuer
+func (sf SubmissionFiles) Value() (driver.Value, error) {
+	return jsonColumnValue(sf)
+}
+
+// Scan implementation of sql.Scanner
+func (sf *SubmissionFiles) Scan(src interface{}) error {
+	return jsonColumnScan(src, sf, "submission files")
+}
+
+// TestResult of a single test within the submission
+type TestResult struct {
+	Name     string  `json:"name"`
+	Pass     bool    `json:"pass"`
+	Score    float64 `json:"score"`
+	MaxScore float64 `json:"max_score"`
+	Message  string  `json:"message,omitempty"`
+	Package  string  `json:"package,omitempty"`
+	Status   string  `json:"status,omitempty"`
+	Elapsed  float64 `json:"elapsed,omitempty"`
+	Output   string  `json:"output,omitempty"`
+}
+
+type TestResults []TestResult
+
+// Value implementation of driver.Valuer
+func (tr TestResults) Value() (driver.Value, error) {
+	return jsonColumnValue(tr)
+}
+
+// Scan implementation of sql.Scanner
+func (tr *TestResults) Scan(src interface{}) error {
+	return jsonColumnScan(src, tr, "test results")
+}
+
+// PackageResult of the go test run
+type PackageResult struct {
+	Name     string   `json:"name"`
+	Status   string   `json:"status"`
+	Elapsed  float64  `json:"elapsed"`
+	Coverage *float64 `json:"coverage,omitempty"`
+	Output   string   `json:"output,omitempty"`
+}
+
+type PackageResults []PackageResult
+
+// Value implementation of driver.Valuer
+func (pr PackageResults) Value() (driver.Value, error) {
+	return jsonColumnValue(pr)
+}
+
+// Scan implementation of sql.Scanner
+func (pr *PackageResults) Scan(src interface{}) error {
+	return jsonColumnScan(src, pr, "package results")
 }
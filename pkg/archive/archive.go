@@ -0,0 +1,189 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+var (
+	// ErrUnsupported archive format, only zip and tar.gz are unpacked
+	ErrUnsupported = errors.New("unsupported archive format")
+	// ErrUnsafe entry of the archive, e.g. a symlink or a path escaping the archive root
+	ErrUnsafe = errors.New("unsafe archive entry")
+	// ErrTooLarge archive contents exceeding the limits
+	ErrTooLarge = errors.New("archive is too large")
+)
+
+// Limits of the unpacked contents, the sizes are checked against the bytes actually read
+type Limits struct {
+	MaxFiles     int
+	MaxFileSize  int64
+	MaxTotalSize int64
+}
+
+// File unpacked from the archive
+type File struct {
+	// Path relative to the archive root, slash separated
+	Path    string
+	Content []byte
+}
+
+// IsArchive name of the supported format
+func IsArchive(name string) bool {
+	return format(name) != ""
+}
+
+func format(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	default:
+		return ""
+	}
+}
+
+// Extract regular files of the archive in memory, directories are skipped and any other entry type is refused
+func Extract(r io.ReaderAt, size int64, name string, limits Limits) ([]File, error) {
+	u := &unpacker{limits: limits, seen: make(map[string]bool)}
+
+	switch format(name) {
+	case "zip":
+		return u.zip(r, size)
+	case "tar.gz":
+		return u.tarGz(io.NewSectionReader(r, 0, size))
+	default:
+		return nil, fmt.Errorf("%s: %w", name, ErrUnsupported)
+	}
+}
+
+type unpacker struct {
+	limits Limits
+	files  []File
+	seen   map[string]bool
+	total  int64
+}
+
+func (u *unpacker) zip(r io.ReaderAt, size int64) ([]File, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("zip: %w", err)
+	}
+
+	for _, f := range zr.File {
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			continue
+		case !mode.IsRegular():
+			return nil, fmt.Errorf("%s: not a regular file: %w", f.Name, ErrUnsafe)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("zip %s: %w", f.Name, err)
+		}
+		err = u.add(f.Name, rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return u.files, nil
+}
+
+func (u *unpacker) tarGz(r io.Reader) ([]File, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("gzip: %w", err)
+	}
+	defer func() {
+		_ = gz.Close()
+	}()
+
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return u.files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tar: %w", err)
+		}
+
+		switch h.Typeflag {
+		case tar.TypeDir, tar.TypeXGlobalHeader:
+			continue
+		case tar.TypeReg:
+		default:
+			return nil, fmt.Errorf("%s: not a regular file: %w", h.Name, ErrUnsafe)
+		}
+
+		if err := u.add(h.Name, tr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// add the entry read up to the limits, the declared sizes are not trusted
+func (u *unpacker) add(name string, r io.Reader) error {
+	p, err := cleanPath(name)
+	if err != nil {
+		return err
+	}
+	if u.seen[p] {
+		return fmt.Errorf("%s: duplicate entry: %w", p, ErrUnsafe)
+	}
+	u.seen[p] = true
+
+	if u.limits.MaxFiles > 0 && len(u.files) >= u.limits.MaxFiles {
+		return fmt.Errorf("more than %d files: %w", u.limits.MaxFiles, ErrTooLarge)
+	}
+
+	limit := u.limits.MaxFileSize
+	if u.limits.MaxTotalSize > 0 && (limit <= 0 || u.limits.MaxTotalSize-u.total < limit) {
+		limit = u.limits.MaxTotalSize - u.total
+	}
+
+	buf := new(bytes.Buffer)
+	src := r
+	if limit > 0 {
+		src = io.LimitReader(r, limit+1)
+	}
+	n, err := io.Copy(buf, src)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p, err)
+	}
+	if limit > 0 && n > limit {
+		return fmt.Errorf("%s: %w", p, ErrTooLarge)
+	}
+
+	u.total += n
+	u.files = append(u.files, File{Path: p, Content: buf.Bytes()})
+
+	return nil
+}
+
+// cleanPath of the entry, absolute paths and the ones escaping the root are refused
+func cleanPath(name string) (string, error) {
+	if strings.Contains(name, "\\") || strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("%s: %w", name, ErrUnsafe)
+	}
+
+	p := path.Clean(name)
+	if p == "." || p == ".." || strings.HasPrefix(p, "../") {
+		return "", fmt.Errorf("%s: %w", name, ErrUnsafe)
+	}
+
+	return p, nil
+}
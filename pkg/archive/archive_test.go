@@ -0,0 +1,152 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type entry struct {
+	name    string
+	content string
+	symlink bool
+}
+
+func zipArchive(t *testing.T, entries []entry) []byte {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for _, e := range entries {
+		h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		if e.symlink {
+			h.SetMode(os.ModeSymlink | 0777)
+		}
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func tarGzArchive(t *testing.T, entries []entry) []byte {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		h := &tar.Header{Name: e.name, Mode: 0644, Size: int64(len(e.content)), Typeflag: tar.TypeReg}
+		switch {
+		case e.symlink:
+			h = &tar.Header{Name: e.name, Linkname: e.content, Typeflag: tar.TypeSymlink}
+		case strings.HasSuffix(e.name, "/"):
+			h = &tar.Header{Name: e.name, Mode: 0755, Typeflag: tar.TypeDir}
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if h.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtract(t *testing.T) {
+	limits := Limits{MaxFiles: 3, MaxFileSize: 1024, MaxTotalSize: 1536}
+	big := strings.Repeat("0", 1025)
+
+	tests := []struct {
+		name      string
+		entries   []entry
+		wantPaths []string
+		wantErr   error
+	}{
+		{
+			name:      "package",
+			entries:   []entry{{name: "hw1/"}, {name: "hw1/main.go", content: "package main"}, {name: "hw1/./game/game.go", content: "package game"}},
+			wantPaths: []string{"hw1/main.go", "hw1/game/game.go"},
+		},
+		{
+			name:    "path traversal",
+			entries: []entry{{name: "hw1/../../etc/passwd", content: "x"}},
+			wantErr: ErrUnsafe,
+		},
+		{
+			name:    "absolute path",
+			entries: []entry{{name: "/etc/passwd", content: "x"}},
+			wantErr: ErrUnsafe,
+		},
+		{
+			name:    "symlink",
+			entries: []entry{{name: "passwd", content: "/etc/passwd", symlink: true}},
+			wantErr: ErrUnsafe,
+		},
+		{
+			name:    "duplicate",
+			entries: []entry{{name: "main.go", content: "a"}, {name: "./main.go", content: "b"}},
+			wantErr: ErrUnsafe,
+		},
+		{
+			name:    "oversized entry",
+			entries: []entry{{name: "main.go", content: big}},
+			wantErr: ErrTooLarge,
+		},
+		{
+			name:    "total size",
+			entries: []entry{{name: "a.go", content: big[:1000]}, {name: "b.go", content: big[:1000]}},
+			wantErr: ErrTooLarge,
+		},
+		{
+			name:    "too many files",
+			entries: []entry{{name: "a.go"}, {name: "b.go"}, {name: "c.go"}, {name: "d.go"}},
+			wantErr: ErrTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, f := range []struct {
+			ext  string
+			make func(*testing.T, []entry) []byte
+		}{{".zip", zipArchive}, {".tar.gz", tarGzArchive}} {
+			t.Run(tt.name+f.ext, func(t *testing.T) {
+				data := f.make(t, tt.entries)
+
+				files, err := Extract(bytes.NewReader(data), int64(len(data)), "submission"+f.ext, limits)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Extract() error = %v, want %v", err, tt.wantErr)
+				}
+
+				var paths []string
+				for _, f := range files {
+					paths = append(paths, f.Path)
+				}
+				if err == nil && !reflect.DeepEqual(paths, tt.wantPaths) {
+					t.Errorf("Extract() paths = %v, want %v", paths, tt.wantPaths)
+				}
+			})
+		}
+	}
+
+	if _, err := Extract(bytes.NewReader(nil), 0, "submission.rar", limits); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Extract() error = %v, want %v", err, ErrUnsupported)
+	}
+}
@@ -2,13 +2,18 @@ package httpserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"net"
 	"net/http"
 	"time"
 )
 
+// connKey of the request context holding the connection
+type connKey struct{}
+
 type Config struct {
 	Listen       string        `mapstructure:"listen"`
 	TimeoutRead  time.Duration `mapstructure:"timeout_read"`
@@ -36,6 +41,7 @@ func New(cfg Config, handler http.Handler, opts ...Option) (*Server, error) {
 		WriteTimeout: cfg.TimeoutWrite,
 		IdleTimeout:  cfg.TimeoutIdle,
 		Handler:      handler,
+		ConnContext:  withConn,
 	}
 
 	s := &Server{
@@ -66,3 +72,18 @@ func (s *Server) Stop() {
 		s.logger.Error().Err(fmt.Errorf("server shutdown: %w", err)).Send()
 	}
 }
+
+// withConn keeps the connection in the request context for ExtendWriteDeadline
+func withConn(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, c)
+}
+
+// ExtendWriteDeadline of the request connection to d from now, the write timeout of the server is counted
+// from the request start, so the handlers streaming or answering late extend it right before writing
+func ExtendWriteDeadline(r *http.Request, d time.Duration) error {
+	c, ok := r.Context().Value(connKey{}).(net.Conn)
+	if !ok {
+		return errors.New("request connection is unknown")
+	}
+	return c.SetWriteDeadline(time.Now().Add(d))
+}
@@ -0,0 +1,58 @@
+package httpserver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExtendWriteDeadline(t *testing.T) {
+	tests := []struct {
+		name   string
+		extend bool
+		ok     bool
+	}{
+		{name: "extended", extend: true, ok: true},
+		{name: "server timeout", extend: false, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(200 * time.Millisecond)
+				if tt.extend {
+					if err := ExtendWriteDeadline(r, time.Second); err != nil {
+						t.Errorf("ExtendWriteDeadline() error = %v", err)
+					}
+				}
+				_, _ = w.Write([]byte("late"))
+			}))
+			srv.Config.WriteTimeout = 50 * time.Millisecond
+			srv.Config.ConnContext = withConn
+			srv.Start()
+			defer srv.Close()
+
+			resp, err := http.Get(srv.URL)
+			if err == nil {
+				var body []byte
+				body, err = ioutil.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+				if err == nil && string(body) != "late" {
+					t.Errorf("body = %q, want %q", body, "late")
+				}
+			}
+			if (err == nil) != tt.ok {
+				t.Errorf("request error = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestExtendWriteDeadline_unknownConn(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if err := ExtendWriteDeadline(r, time.Second); err == nil {
+		t.Error("ExtendWriteDeadline() error = nil, want error")
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"grader/pkg/apperr"
+	"grader/pkg/httputil"
+	"net/http"
+	"strings"
+)
+
+// BearerToken required from the clients, requests pass through if the token is empty
+func BearerToken(token string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if token == "" {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := r.Header.Get("Authorization")
+			got := strings.TrimPrefix(h, "Bearer ")
+			if got == h || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				httputil.WriteError(w, apperr.ErrUnauthorized, http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
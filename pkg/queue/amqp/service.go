@@ -3,6 +3,7 @@ package amqp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/isayme/go-amqp-reconnect/rabbitmq"
 	"github.com/streadway/amqp"
@@ -10,8 +11,13 @@ import (
 	"grader/pkg/workerpool"
 	"reflect"
 	"runtime"
+	"time"
 )
 
+// retryDelays of the retry queues, the requested delay is rounded up to the closest one,
+// messages of a queue share the TTL so the ones behind the head are never held longer
+var retryDelays = []time.Duration{10 * time.Second, 30 * time.Second, time.Minute, 5 * time.Minute}
+
 var _ queue.Topic = (*Topic)(nil)
 var _ queue.Queue = (*Service)(nil)
 
@@ -85,9 +91,31 @@ func (s *Service) Topic(topic string) (queue.Topic, error) {
 		return nil, fmt.Errorf("queue bind: %w", err)
 	}
 
+	// expired messages of the retry queues are dead-lettered back to the topic
+	retryQueues := make([]string, 0, len(retryDelays))
+	for _, d := range retryDelays {
+		name := fmt.Sprintf("%s-retry-%ds", topic, int(d.Seconds()))
+		if _, err := s.ch.QueueDeclare(
+			name,
+			true,
+			false,
+			false,
+			false,
+			amqp.Table{
+				"x-message-ttl":             d.Milliseconds(),
+				"x-dead-letter-exchange":    topic,
+				"x-dead-letter-routing-key": "",
+			},
+		); err != nil {
+			return nil, fmt.Errorf("retry queue declare: %w", err)
+		}
+		retryQueues = append(retryQueues, name)
+	}
+
 	return &Topic{
 		exchangeName: topic,
 		queueName:    queueName,
+		retryQueues:  retryQueues,
 		channel:      s.Channel(),
 	}, nil
 }
@@ -95,7 +123,9 @@ func (s *Service) Topic(topic string) (queue.Topic, error) {
 type Topic struct {
 	exchangeName string
 	queueName    string
-	channel      *rabbitmq.Channel
+	// retryQueues of the retryDelays
+	retryQueues []string
+	channel     *rabbitmq.Channel
 }
 
 // Publish message in the topic
@@ -129,6 +159,12 @@ func (t *Topic) Consume(targetMsg interface{}, consumer queue.ConsumerFunc) erro
 	}
 
 	msgType := val.Type()
+	numWorkers := runtime.GOMAXPROCS(0) * 2
+
+	// unacknowledged deliveries are limited to the ones being processed, the rest stay in the queue
+	if err := t.channel.Qos(numWorkers, 0, false); err != nil {
+		return fmt.Errorf("qos: %w", err)
+	}
 
 	messages, err := t.channel.Consume(
 		t.queueName,
@@ -145,10 +181,10 @@ func (t *Topic) Consume(targetMsg interface{}, consumer queue.ConsumerFunc) erro
 	}
 
 	pool := workerpool.New()
-	pool.Start(runtime.GOMAXPROCS(0) * 2)
+	pool.Start(numWorkers)
 
 	for amqpMsg := range messages {
-		pool.Run(processMessage(amqpMsg, msgType, consumer))
+		pool.Run(t.processMessage(amqpMsg, msgType, consumer))
 	}
 
 	pool.Stop()
@@ -156,7 +192,7 @@ func (t *Topic) Consume(targetMsg interface{}, consumer queue.ConsumerFunc) erro
 	return nil
 }
 
-func processMessage(amqpMsg amqp.Delivery, msgType reflect.Type, consumer queue.ConsumerFunc) workerpool.Job {
+func (t *Topic) processMessage(amqpMsg amqp.Delivery, msgType reflect.Type, consumer queue.ConsumerFunc) workerpool.Job {
 	return func(ctx context.Context) error {
 		v := reflect.New(msgType).Interface()
 
@@ -168,7 +204,20 @@ func processMessage(amqpMsg amqp.Delivery, msgType reflect.Type, consumer queue.
 		}
 
 		if err := consumer(ctx, v); err != nil {
-			if err := amqpMsg.Reject(true); err != nil {
+			// the message is requeued at once if it can not be delayed
+			var retryErr *queue.RetryError
+			if errors.As(err, &retryErr) {
+				if pubErr := t.retry(amqpMsg, retryErr.After); pubErr == nil {
+					if err := amqpMsg.Ack(false); err != nil {
+						return fmt.Errorf("amqp acknowledge: %w", err)
+					}
+					return fmt.Errorf("consumer: %w", err)
+				}
+			}
+
+			// drop messages which will never be processed successfully
+			requeue := !errors.Is(err, queue.ErrPermanent)
+			if err := amqpMsg.Reject(requeue); err != nil {
 				return fmt.Errorf("amqp reject: %w", err)
 			}
 			return fmt.Errorf("consumer: %w", err)
@@ -181,3 +230,26 @@ func processMessage(amqpMsg amqp.Delivery, msgType reflect.Type, consumer queue.
 		return nil
 	}
 }
+
+// retry the message after the delay through the retry queue
+func (t *Topic) retry(amqpMsg amqp.Delivery, d time.Duration) error {
+	name := t.retryQueues[len(t.retryQueues)-1]
+	for i, delay := range retryDelays {
+		if d <= delay {
+			name = t.retryQueues[i]
+			break
+		}
+	}
+
+	// published to the queue directly by the default exchange
+	if err := t.channel.Publish("", name, false, false, amqp.Publishing{
+		Headers:      amqpMsg.Headers,
+		DeliveryMode: amqp.Persistent,
+		ContentType:  amqpMsg.ContentType,
+		Body:         amqpMsg.Body,
+	}); err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+
+	return nil
+}
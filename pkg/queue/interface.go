@@ -1,6 +1,34 @@
 package queue
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPermanent marks consumer errors which must not lead to message redelivery
+var ErrPermanent = errors.New("permanent failure")
+
+// RetryError of the consumer asks to deliver the message again after the delay,
+// the consumer is free to take the other messages meanwhile
+type RetryError struct {
+	After time.Duration
+	Err   error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("retry after %s: %v", e.After, e.Err)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter the delay, the message is requeued at once if the delay is not supported by the queue
+func RetryAfter(d time.Duration, err error) error {
+	return &RetryError{After: d, Err: err}
+}
 
 type Queue interface {
 	Topic(topic string) (Topic, error)
@@ -12,13 +12,28 @@ var _ Manager = (*JWT)(nil)
 
 var ErrInvalidToken = errors.New("invalid token")
 
+// MinSecretKeyLength of the HMAC key, shorter keys are refused
+const MinSecretKeyLength = 32
+
 type JWT struct {
 	secretKey []byte
+	audience  string
 }
 
 func NewJWT(secretKey string) (*JWT, error) {
+	return NewAudienceJWT(secretKey, "")
+}
+
+// NewAudienceJWT issues tokens for the audience only, tokens of other audiences signed with the same key are invalid
+func NewAudienceJWT(secretKey, audience string) (*JWT, error) {
+	// anyone could sign the tokens with an empty or guessable key
+	if len(secretKey) < MinSecretKeyLength {
+		return nil, fmt.Errorf("secret key of at least %d bytes is required", MinSecretKeyLength)
+	}
+
 	return &JWT{
 		secretKey: []byte(secretKey),
+		audience:  audience,
 	}, nil
 }
 
@@ -37,6 +52,7 @@ func (tm *JWT) Issue(id Identity, lifetime time.Duration) (string, error) {
 	data := JWTClaims{
 		StandardClaims: jwt.StandardClaims{
 			Id:        id.Identity(),
+			Audience:  tm.audience,
 			ExpiresAt: exp.Unix(),
 			IssuedAt:  now.Unix(),
 			NotBefore: now.Unix(),
@@ -54,7 +70,7 @@ func (tm *JWT) Decode(decode string) (Identity, error) {
 		return nil, fmt.Errorf("token parse: %w", err)
 	}
 
-	if payload.Valid() != nil {
+	if payload.Valid() != nil || payload.Audience != tm.audience {
 		return nil, ErrInvalidToken
 	}
 
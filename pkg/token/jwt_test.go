@@ -0,0 +1,65 @@
+package token
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKey = "0123456789abcdef0123456789abcdef"
+
+type identity string
+
+func (i identity) Identity() string {
+	return string(i)
+}
+
+func TestJWT_Validate(t *testing.T) {
+	sessions, _ := NewJWT(testKey)
+	callbacks, _ := NewAudienceJWT(testKey, "callback")
+	otherKey, _ := NewAudienceJWT(strings.Repeat("x", MinSecretKeyLength), "callback")
+
+	tests := []struct {
+		name     string
+		issuer   *JWT
+		id       identity
+		lifetime time.Duration
+		wantErr  bool
+	}{
+		{name: "valid", issuer: callbacks, id: "a", lifetime: time.Hour},
+		{name: "expired", issuer: callbacks, id: "a", lifetime: -time.Minute, wantErr: true},
+		{name: "another identity", issuer: callbacks, id: "b", lifetime: time.Hour, wantErr: true},
+		{name: "another audience", issuer: sessions, id: "a", lifetime: time.Hour, wantErr: true},
+		{name: "another key", issuer: otherKey, id: "a", lifetime: time.Hour, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tk, err := tt.issuer.Issue(tt.id, tt.lifetime)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := callbacks.Validate(tk, identity("a")); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// callback tokens do not pass for the session ones either
+	tk, _ := callbacks.Issue(identity("a"), time.Hour)
+	if err := sessions.Validate(tk, identity("a")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("session Validate() error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestNewJWT(t *testing.T) {
+	for _, key := range []string{"", "secret", testKey[:MinSecretKeyLength-1]} {
+		if _, err := NewJWT(key); err == nil {
+			t.Errorf("NewJWT(%q) error = nil, want weak key error", key)
+		}
+	}
+	if _, err := NewJWT(testKey); err != nil {
+		t.Errorf("NewJWT() error = %v", err)
+	}
+}
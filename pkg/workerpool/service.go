@@ -2,6 +2,7 @@ package workerpool
 
 import (
 	"context"
+	"errors"
 	"github.com/rs/xid"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -11,12 +12,20 @@ import (
 
 type Job func(ctx context.Context) error
 
+var (
+	// ErrQueueFull is returned when there is no room for the job in the queue
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrStopped is returned when the pool does not accept jobs anymore
+	ErrStopped = errors.New("pool is stopped")
+)
+
 type Pool struct {
 	wg     *sync.WaitGroup
 	logger zerolog.Logger
 
-	jobs chan Job
-	stop chan struct{}
+	jobs      chan Job
+	stop      chan struct{}
+	queueSize int
 
 	DefaultContext func() context.Context
 }
@@ -46,10 +55,17 @@ func WithLogger(zl zerolog.Logger) PoolOption {
 	}
 }
 
+// WithQueueSize of the jobs waiting for a free worker, submissions block or fail when it is full
+func WithQueueSize(n int) PoolOption {
+	return func(service *Pool) {
+		service.queueSize = n
+	}
+}
+
 func (s *Pool) Start(numWorkers int) {
-	s.logger.Info().Int("worker_num", numWorkers).Msg("Starting workers")
+	s.logger.Info().Int("worker_num", numWorkers).Int("queue_size", s.queueSize).Msg("Starting workers")
 	s.stop = make(chan struct{})
-	s.jobs = make(chan Job)
+	s.jobs = make(chan Job, s.queueSize)
 	s.wg.Add(numWorkers)
 	for i := 0; i < numWorkers; i++ {
 		go func(workerID int) {
@@ -84,10 +100,50 @@ func (s *Pool) Stop() {
 	s.logger.Info().Msg("Shutting down workers")
 	close(s.stop)
 	s.wg.Wait()
-	close(s.jobs)
-	s.logger.Info().Msg("Done shutting down workers")
+	// jobs channel stays open as submitters may still race with stop
+	s.logger.Info().Int("jobs_dropped", len(s.jobs)).Msg("Done shutting down workers")
 }
 
+// Run the job waiting for a free worker or a room in the queue
 func (s *Pool) Run(job Job) {
-	s.jobs <- job
+	_ = s.Submit(context.Background(), job)
+}
+
+// Submit the job waiting until it is accepted, the context is done or the pool is stopped
+func (s *Pool) Submit(ctx context.Context, job Job) error {
+	select {
+	case <-s.stop:
+		return ErrStopped
+	default:
+	}
+
+	select {
+	case s.jobs <- job:
+		return nil
+	case <-s.stop:
+		return ErrStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TrySubmit the job without waiting, ErrQueueFull is returned if no worker or queue slot is free
+func (s *Pool) TrySubmit(job Job) error {
+	select {
+	case <-s.stop:
+		return ErrStopped
+	default:
+	}
+
+	select {
+	case s.jobs <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// QueueLen of the jobs waiting for a free worker
+func (s *Pool) QueueLen() int {
+	return len(s.jobs)
 }
@@ -0,0 +1,48 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPool_TrySubmit(t *testing.T) {
+	p := New(WithQueueSize(1))
+	p.Start(1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	blocking := func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}
+	noop := func(ctx context.Context) error { return nil }
+
+	if err := p.TrySubmit(blocking); err != nil {
+		t.Fatalf("TrySubmit() error = %v", err)
+	}
+	<-started
+
+	// the only worker is busy, the queue has room for one more
+	if err := p.TrySubmit(noop); err != nil {
+		t.Fatalf("TrySubmit() error = %v", err)
+	}
+	if err := p.TrySubmit(noop); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("TrySubmit() error = %v, want %v", err, ErrQueueFull)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Submit(ctx, noop); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Submit() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(release)
+	p.Stop()
+
+	if err := p.TrySubmit(noop); !errors.Is(err, ErrStopped) {
+		t.Errorf("TrySubmit() error = %v, want %v", err, ErrStopped)
+	}
+}